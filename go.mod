@@ -21,6 +21,7 @@ require (
 	github.com/google/uuid v1.6.0
 	github.com/mitchellh/hashstructure v1.1.0
 	github.com/nais/pgrator/pkg/api v0.0.0-20260702113208-b469e505b1d5
+	github.com/robfig/cron/v3 v3.0.1
 	github.com/sirupsen/logrus v1.10.0
 	github.com/spf13/pflag v1.0.10
 	github.com/spf13/viper v1.21.0
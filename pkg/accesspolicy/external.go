@@ -0,0 +1,76 @@
+package accesspolicy
+
+import (
+	"fmt"
+
+	fqdn "github.com/nais/liberator/pkg/apis/fqdnnetworkpolicies.networking.gke.io/v1alpha3"
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// DefaultExternalPort is allowed for external rules without ports, as external traffic defaults to HTTPS.
+const DefaultExternalPort = 443
+
+// ExternalEgress converts external access policy rules to egress rules.
+// Rules with a host become FQDNNetworkPolicy egress rules, and rules with an IP address or a CIDR range
+// become NetworkPolicy egress rules with an ipBlock. Rules are converted in order, one egress rule each.
+// Ports without a protocol use TCP, and rules without ports allow DefaultExternalPort.
+func ExternalEgress(rules []nais_io_v1.AccessPolicyExternalRule) ([]fqdn.FQDNNetworkPolicyEgressRule, []networkingv1.NetworkPolicyEgressRule, error) {
+	var fqdnRules []fqdn.FQDNNetworkPolicyEgressRule
+	var ipRules []networkingv1.NetworkPolicyEgressRule
+
+	for i, rule := range rules {
+		ports := NetworkPolicyPorts(rule.Ports)
+
+		prefix, ok, err := rule.Prefix()
+		switch {
+		case err != nil:
+			return nil, nil, fmt.Errorf("external rule %d: %w", i, err)
+		case ok:
+			ipRules = append(ipRules, networkingv1.NetworkPolicyEgressRule{
+				Ports: ports,
+				To: []networkingv1.NetworkPolicyPeer{
+					{IPBlock: &networkingv1.IPBlock{CIDR: prefix.String()}},
+				},
+			})
+		case len(rule.Host) > 0:
+			fqdnRules = append(fqdnRules, fqdn.FQDNNetworkPolicyEgressRule{
+				Ports: ports,
+				To: []fqdn.FQDNNetworkPolicyPeer{
+					{FQDNs: []string{rule.Host}},
+				},
+			})
+		default:
+			return nil, nil, fmt.Errorf("external rule %d: no host, address or network", i)
+		}
+	}
+
+	return fqdnRules, ipRules, nil
+}
+
+// NetworkPolicyPorts converts port rules to NetworkPolicy ports.
+// Ports without a protocol use TCP, and an empty list allows DefaultExternalPort.
+func NetworkPolicyPorts(rules []nais_io_v1.AccessPolicyPortRule) []networkingv1.NetworkPolicyPort {
+	if len(rules) == 0 {
+		rules = []nais_io_v1.AccessPolicyPortRule{{Port: DefaultExternalPort}}
+	}
+
+	ports := make([]networkingv1.NetworkPolicyPort, len(rules))
+	for i, rule := range rules {
+		protocol := portProtocol(rule)
+		ports[i] = networkingv1.NetworkPolicyPort{
+			Protocol: &protocol,
+			Port:     new(intstr.FromInt32(int32(rule.Port))),
+		}
+	}
+	return ports
+}
+
+func portProtocol(rule nais_io_v1.AccessPolicyPortRule) corev1.Protocol {
+	if len(rule.Protocol) == 0 {
+		return corev1.ProtocolTCP
+	}
+	return rule.Protocol
+}
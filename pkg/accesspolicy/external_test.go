@@ -0,0 +1,70 @@
+package accesspolicy_test
+
+import (
+	"testing"
+
+	"github.com/nais/liberator/pkg/accesspolicy"
+	fqdn "github.com/nais/liberator/pkg/apis/fqdnnetworkpolicies.networking.gke.io/v1alpha3"
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func port(protocol corev1.Protocol, port int32) networkingv1.NetworkPolicyPort {
+	return networkingv1.NetworkPolicyPort{Protocol: &protocol, Port: new(intstr.FromInt32(port))}
+}
+
+func TestExternalEgress(t *testing.T) {
+	fqdnRules, ipRules, err := accesspolicy.ExternalEgress([]nais_io_v1.AccessPolicyExternalRule{
+		{Host: "example.com"},
+		{Host: "dns.example.org", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 53, Protocol: corev1.ProtocolUDP}, {Port: 53}}},
+		{IPv4: "10.0.0.1"},
+		{IPv6: "2001:db8::1", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 5432}}},
+		{CIDR: "192.168.0.0/16"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []fqdn.FQDNNetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 443)},
+			To:    []fqdn.FQDNNetworkPolicyPeer{{FQDNs: []string{"example.com"}}},
+		},
+		{
+			Ports: []networkingv1.NetworkPolicyPort{port(corev1.ProtocolUDP, 53), port(corev1.ProtocolTCP, 53)},
+			To:    []fqdn.FQDNNetworkPolicyPeer{{FQDNs: []string{"dns.example.org"}}},
+		},
+	}, fqdnRules)
+
+	assert.Equal(t, []networkingv1.NetworkPolicyEgressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 443)},
+			To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.1/32"}}},
+		},
+		{
+			Ports: []networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 5432)},
+			To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "2001:db8::1/128"}}},
+		},
+		{
+			Ports: []networkingv1.NetworkPolicyPort{port(corev1.ProtocolTCP, 443)},
+			To:    []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "192.168.0.0/16"}}},
+		},
+	}, ipRules)
+}
+
+func TestExternalEgress_Invalid(t *testing.T) {
+	for name, rule := range map[string]nais_io_v1.AccessPolicyExternalRule{
+		"empty":          {},
+		"invalid ipv4":   {IPv4: "10.0.0"},
+		"ipv4 as ipv6":   {IPv6: "10.0.0.1"},
+		"host bits set":  {CIDR: "10.0.0.1/8"},
+		"missing prefix": {CIDR: "10.0.0.0"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := accesspolicy.ExternalEgress([]nais_io_v1.AccessPolicyExternalRule{{Host: "example.com"}, rule})
+			assert.ErrorContains(t, err, "external rule 1")
+		})
+	}
+}
@@ -0,0 +1,244 @@
+// Package accesspolicy resolves the access policies of Applications and Naisjobs into a graph of which workloads may communicate.
+//
+// Communication from one workload to another is only allowed when the caller has an outbound rule for the callee,
+// and the callee has an inbound rule for the caller. The graph is built from the workloads of a single cluster.
+// Rules that refer to other clusters are kept, but cannot be checked against the other side.
+package accesspolicy
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Workload is implemented by Applications and Naisjobs.
+type Workload interface {
+	metav1.Object
+	GetAccessPolicy() *nais_io_v1.AccessPolicy
+}
+
+// Peer identifies a workload that is a party of an access policy.
+// In rules with wildcards, Namespace or Application may be nais_io_v1.AccessPolicyWildcard.
+//
+// Peers do not have a kind. Access policy rules refer to workloads by name, and network policies select pods
+// by the app label, so an Application and a Naisjob with the same name in the same namespace are the same peer.
+type Peer struct {
+	Cluster     string
+	Namespace   string
+	Application string
+}
+
+func (p Peer) String() string {
+	return fmt.Sprintf("%s/%s/%s", p.Cluster, p.Namespace, p.Application)
+}
+
+func (p Peer) compare(other Peer) int {
+	return strings.Compare(p.String(), other.String())
+}
+
+// Direction is the direction of an access policy rule, as seen from the workload that has the rule.
+type Direction string
+
+const (
+	Inbound  Direction = "inbound"
+	Outbound Direction = "outbound"
+)
+
+func (d Direction) opposite() Direction {
+	if d == Inbound {
+		return Outbound
+	}
+	return Inbound
+}
+
+// Rule is an access policy rule, resolved to the peer it refers to.
+type Rule struct {
+	// Owner is the workload that has the rule.
+	Owner     Peer
+	Direction Direction
+	Peer      Peer
+	// Team is the team selected by the rule, if any.
+	Team string
+}
+
+func (r Rule) String() string {
+	s := fmt.Sprintf("%s %s rule for %s", r.Owner, r.Direction, r.Peer)
+	if len(r.Team) > 0 {
+		s += " in team " + r.Team
+	}
+	return s
+}
+
+// selector returns the rule as an AccessPolicyRule, for matching against workloads.
+func (r Rule) selector() nais_io_v1.AccessPolicyRule {
+	return nais_io_v1.AccessPolicyRule{
+		Application: r.Peer.Application,
+		Namespace:   r.Peer.Namespace,
+		Cluster:     r.Peer.Cluster,
+		Team:        r.Team,
+	}
+}
+
+type node struct {
+	team  string
+	rules map[Direction][]Rule
+}
+
+// Graph holds the access policy rules of all workloads in a cluster.
+type Graph struct {
+	cluster string
+	nodes   map[Peer]*node
+	rules   []Rule
+}
+
+// New builds a graph from all the workloads in a cluster.
+// Rules without a namespace refer to the namespace of the workload that has the rule,
+// and rules without a cluster refer to the given cluster.
+//
+// Workloads with the same peer, i.e. an Application and a Naisjob with the same name in the same namespace,
+// share a single node with the rules of both. The team of the node is taken from the first of them.
+func New(cluster string, workloads ...Workload) *Graph {
+	g := &Graph{
+		cluster: cluster,
+		nodes:   map[Peer]*node{},
+	}
+
+	for _, w := range workloads {
+		owner := g.Peer(w)
+		n := g.nodes[owner]
+		if n == nil {
+			n = &node{team: nais_io_v1.WorkloadTeam(w), rules: map[Direction][]Rule{}}
+			g.nodes[owner] = n
+		}
+
+		policy := w.GetAccessPolicy()
+		if policy == nil {
+			continue
+		}
+		if policy.Inbound != nil {
+			g.addRules(owner, n, Inbound, policy.Inbound.Rules)
+		}
+		if policy.Outbound != nil {
+			g.addRules(owner, n, Outbound, policy.Outbound.Rules)
+		}
+	}
+
+	slices.SortFunc(g.rules, func(a, b Rule) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return g
+}
+
+// Peer returns the peer that identifies a workload in the cluster of the graph.
+func (g *Graph) Peer(w Workload) Peer {
+	return Peer{Cluster: g.cluster, Namespace: w.GetNamespace(), Application: w.GetName()}
+}
+
+// Resolve returns the peer that a rule of the given workload refers to.
+func (g *Graph) Resolve(owner Peer, rule nais_io_v1.AccessPolicyRule) Peer {
+	rule = rule.WithNamespace(owner.Namespace)
+	peer := Peer{Cluster: rule.Cluster, Namespace: rule.Namespace, Application: rule.Application}
+	if rule.MatchesCluster(g.cluster) {
+		peer.Cluster = g.cluster
+	}
+	return peer
+}
+
+func (g *Graph) addRules(owner Peer, n *node, direction Direction, rules nais_io_v1.AccessPolicyBaseRules) {
+	for _, rule := range rules.GetRules() {
+		r := Rule{Owner: owner, Direction: direction, Peer: g.Resolve(owner, rule), Team: rule.Team}
+		g.rules = append(g.rules, r)
+		n.rules[direction] = append(n.rules[direction], r)
+	}
+}
+
+// Rules returns all the rules in the graph.
+func (g *Graph) Rules() []Rule {
+	return slices.Clone(g.rules)
+}
+
+// Inbound returns the peers in the cluster that may call the given workload,
+// i.e. the peers it has inbound rules for, that have an outbound rule for it.
+func (g *Graph) Inbound(p Peer) []Peer {
+	return g.effective(p, Inbound)
+}
+
+// Outbound returns the peers in the cluster that the given workload may call,
+// i.e. the peers it has outbound rules for, that have an inbound rule for it.
+func (g *Graph) Outbound(p Peer) []Peer {
+	return g.effective(p, Outbound)
+}
+
+func (g *Graph) effective(p Peer, direction Direction) []Peer {
+	if g.nodes[p] == nil {
+		return nil
+	}
+
+	var peers []Peer
+	for peer := range g.nodes {
+		if g.allows(p, direction, peer) && g.allows(peer, direction.opposite(), p) {
+			peers = append(peers, peer)
+		}
+	}
+	slices.SortFunc(peers, Peer.compare)
+	return peers
+}
+
+// OneSided returns the rules matching workloads in the cluster, where none of the matched workloads
+// has a rule in the other direction for the owner of the rule. Such rules do not allow any communication.
+func (g *Graph) OneSided() []Rule {
+	var rules []Rule
+	for _, rule := range g.rules {
+		matched := g.matching(rule)
+		if len(matched) == 0 {
+			continue
+		}
+		if !slices.ContainsFunc(matched, func(peer Peer) bool {
+			return g.allows(peer, rule.Direction.opposite(), rule.Owner)
+		}) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Dangling returns the rules for the cluster that do not match any workload in it.
+func (g *Graph) Dangling() []Rule {
+	var rules []Rule
+	for _, rule := range g.rules {
+		if rule.Peer.Cluster == g.cluster && len(g.matching(rule)) == 0 {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// allows returns true if the workload has a rule in the given direction that matches the peer.
+func (g *Graph) allows(owner Peer, direction Direction, peer Peer) bool {
+	n := g.nodes[owner]
+	if n == nil {
+		return false
+	}
+	return slices.ContainsFunc(n.rules[direction], func(rule Rule) bool {
+		return g.matches(rule, peer)
+	})
+}
+
+// matching returns the workloads in the cluster that a rule matches.
+func (g *Graph) matching(rule Rule) []Peer {
+	var peers []Peer
+	for peer := range g.nodes {
+		if g.matches(rule, peer) {
+			peers = append(peers, peer)
+		}
+	}
+	return peers
+}
+
+func (g *Graph) matches(rule Rule, peer Peer) bool {
+	selector := rule.selector()
+	return selector.Matches(peer.Cluster, peer.Namespace, peer.Application) && selector.MatchesTeam(g.nodes[peer].team)
+}
@@ -0,0 +1,153 @@
+package accesspolicy_test
+
+import (
+	"testing"
+
+	"github.com/nais/liberator/pkg/accesspolicy"
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	nais_io_v1alpha1 "github.com/nais/liberator/pkg/apis/nais.io/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const cluster = "dev"
+
+func rules(rules ...nais_io_v1.AccessPolicyRule) []nais_io_v1.AccessPolicyRule {
+	return rules
+}
+
+func policy(inbound, outbound []nais_io_v1.AccessPolicyRule) *nais_io_v1.AccessPolicy {
+	ap := &nais_io_v1.AccessPolicy{
+		Inbound:  &nais_io_v1.AccessPolicyInbound{},
+		Outbound: &nais_io_v1.AccessPolicyOutbound{Rules: outbound},
+	}
+	for _, rule := range inbound {
+		ap.Inbound.Rules = append(ap.Inbound.Rules, nais_io_v1.AccessPolicyInboundRule{AccessPolicyRule: rule})
+	}
+	return ap
+}
+
+func application(namespace, name string, ap *nais_io_v1.AccessPolicy) *nais_io_v1alpha1.Application {
+	return &nais_io_v1alpha1.Application{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       nais_io_v1alpha1.ApplicationSpec{AccessPolicy: ap},
+	}
+}
+
+func naisjob(namespace, name string, ap *nais_io_v1.AccessPolicy) *nais_io_v1.Naisjob {
+	return &nais_io_v1.Naisjob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       nais_io_v1.NaisjobSpec{AccessPolicy: ap},
+	}
+}
+
+func peer(namespace, name string) accesspolicy.Peer {
+	return accesspolicy.Peer{Cluster: cluster, Namespace: namespace, Application: name}
+}
+
+func graph() *accesspolicy.Graph {
+	return accesspolicy.New(cluster,
+		// frontend calls api in the same namespace, and backend in another namespace
+		application("team-a", "frontend", policy(nil, rules(
+			nais_io_v1.AccessPolicyRule{Application: "api"},
+			nais_io_v1.AccessPolicyRule{Application: "backend", Namespace: "team-b"},
+			nais_io_v1.AccessPolicyRule{Application: "missing"},
+			nais_io_v1.AccessPolicyRule{Application: "remote", Namespace: "team-c", Cluster: "prod"},
+		))),
+		application("team-a", "api", policy(rules(
+			nais_io_v1.AccessPolicyRule{Application: "frontend"},
+			nais_io_v1.AccessPolicyRule{Application: "batch", Namespace: "team-b", Cluster: cluster},
+		), nil)),
+		// backend does not allow frontend
+		application("team-b", "backend", policy(rules(
+			nais_io_v1.AccessPolicyRule{Application: "gone", Namespace: "team-a"},
+		), nil)),
+		naisjob("team-b", "batch", policy(nil, rules(
+			nais_io_v1.AccessPolicyRule{Application: "api", Namespace: "team-a"},
+		))),
+		application("team-c", "nopolicy", nil),
+	)
+}
+
+func TestGraph_Effective(t *testing.T) {
+	g := graph()
+
+	assert.Equal(t, []accesspolicy.Peer{peer("team-a", "api")}, g.Outbound(peer("team-a", "frontend")))
+	assert.Equal(t, []accesspolicy.Peer{peer("team-a", "api")}, g.Outbound(peer("team-b", "batch")))
+	assert.Equal(t, []accesspolicy.Peer{peer("team-a", "frontend"), peer("team-b", "batch")}, g.Inbound(peer("team-a", "api")))
+	assert.Empty(t, g.Inbound(peer("team-b", "backend")))
+	assert.Empty(t, g.Outbound(peer("team-c", "nopolicy")))
+	assert.Empty(t, g.Inbound(peer("team-c", "unknown")))
+}
+
+func TestGraph_OneSided(t *testing.T) {
+	assert.Equal(t, []accesspolicy.Rule{
+		{Owner: peer("team-a", "frontend"), Direction: accesspolicy.Outbound, Peer: peer("team-b", "backend")},
+	}, graph().OneSided())
+}
+
+func TestGraph_Dangling(t *testing.T) {
+	assert.Equal(t, []accesspolicy.Rule{
+		{Owner: peer("team-a", "frontend"), Direction: accesspolicy.Outbound, Peer: peer("team-a", "missing")},
+		{Owner: peer("team-b", "backend"), Direction: accesspolicy.Inbound, Peer: peer("team-a", "gone")},
+	}, graph().Dangling())
+}
+
+func TestGraph_Rules(t *testing.T) {
+	g := graph()
+	remote := accesspolicy.Peer{Cluster: "prod", Namespace: "team-c", Application: "remote"}
+
+	assert.Contains(t, g.Rules(), accesspolicy.Rule{Owner: peer("team-a", "frontend"), Direction: accesspolicy.Outbound, Peer: remote})
+	assert.Len(t, g.Rules(), 8)
+}
+
+func TestGraph_Wildcards(t *testing.T) {
+	labeled := func(app *nais_io_v1alpha1.Application, team string) *nais_io_v1alpha1.Application {
+		app.SetLabels(map[string]string{nais_io_v1.TeamLabel: team})
+		return app
+	}
+	g := accesspolicy.New(cluster,
+		// api allows all applications of team-b, wherever they are
+		application("team-a", "api", policy(rules(
+			nais_io_v1.AccessPolicyRule{Application: "*", Namespace: "*", Team: "team-b"},
+		), nil)),
+		labeled(application("team-b", "frontend", policy(nil, rules(
+			nais_io_v1.AccessPolicyRule{Application: "*", Namespace: "team-a"},
+		))), "team-b"),
+		labeled(application("shared", "worker", policy(nil, rules(
+			nais_io_v1.AccessPolicyRule{Application: "api", Namespace: "*"},
+		))), "team-b"),
+		// team-c is not allowed by api
+		application("team-c", "client", policy(nil, rules(
+			nais_io_v1.AccessPolicyRule{Application: "api", Namespace: "team-a"},
+			nais_io_v1.AccessPolicyRule{Application: "*", Namespace: "team-d"},
+		))),
+	)
+
+	assert.Equal(t, []accesspolicy.Peer{peer("shared", "worker"), peer("team-b", "frontend")}, g.Inbound(peer("team-a", "api")))
+	assert.Equal(t, []accesspolicy.Peer{peer("team-a", "api")}, g.Outbound(peer("shared", "worker")))
+	assert.Equal(t, []accesspolicy.Rule{
+		{Owner: peer("team-c", "client"), Direction: accesspolicy.Outbound, Peer: peer("team-a", "api")},
+	}, g.OneSided())
+	assert.Equal(t, []accesspolicy.Rule{
+		{Owner: peer("team-c", "client"), Direction: accesspolicy.Outbound, Peer: peer("team-d", "*")},
+	}, g.Dangling())
+}
+
+func TestGraph_SameNameDifferentKind(t *testing.T) {
+	// The Application only allows outbound traffic, and the Naisjob only inbound,
+	// but as they share a name they are the same peer, and api may communicate with both.
+	g := accesspolicy.New(cluster,
+		application("team-a", "shared", policy(nil, rules(nais_io_v1.AccessPolicyRule{Application: "api"}))),
+		naisjob("team-a", "shared", policy(rules(nais_io_v1.AccessPolicyRule{Application: "api"}), nil)),
+		application("team-a", "api", policy(
+			rules(nais_io_v1.AccessPolicyRule{Application: "shared"}),
+			rules(nais_io_v1.AccessPolicyRule{Application: "shared"}),
+		)),
+	)
+
+	assert.Equal(t, []accesspolicy.Peer{peer("team-a", "api")}, g.Outbound(peer("team-a", "shared")))
+	assert.Equal(t, []accesspolicy.Peer{peer("team-a", "api")}, g.Inbound(peer("team-a", "shared")))
+	assert.Empty(t, g.OneSided())
+	assert.Len(t, g.Rules(), 4)
+}
@@ -0,0 +1,227 @@
+package accesspolicy
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	fqdn "github.com/nais/liberator/pkg/apis/fqdnnetworkpolicies.networking.gke.io/v1alpha3"
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"github.com/nais/liberator/pkg/namegen"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// AppLabel holds the name of the workload that a pod belongs to.
+	AppLabel = "app"
+	// NamespaceLabel is set on all namespaces by Kubernetes, and holds the name of the namespace.
+	NamespaceLabel = "kubernetes.io/metadata.name"
+	// FQDNSuffix is appended to the name of the workload to name its FQDNNetworkPolicy.
+	FQDNSuffix = "fqdn"
+	// DNSPort is allowed over UDP and TCP for egress to Baseline.DNS.
+	DNSPort = 53
+)
+
+// KubeDNS selects the kube-dns pods in the kube-system namespace, for use in Baseline.DNS.
+var KubeDNS = networkingv1.NetworkPolicyPeer{
+	PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"k8s-app": "kube-dns"}},
+	NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{NamespaceLabel: "kube-system"}},
+}
+
+// Baseline is the traffic that every workload is allowed in addition to its access policy.
+// These pods differ between clusters, so there are no defaults, and peers that are not set allow nothing.
+// Peers are used in the order given.
+type Baseline struct {
+	// DNS selects the pods that resolve names, e.g. KubeDNS. Egress to them is allowed on DNSPort.
+	DNS []networkingv1.NetworkPolicyPeer
+	// IngressControllers selects the ingress controllers that forward traffic to the workload.
+	IngressControllers []networkingv1.NetworkPolicyPeer
+	// Prometheus selects the pods that scrape metrics from the workload.
+	Prometheus []networkingv1.NetworkPolicyPeer
+}
+
+// NetworkPolicies translates the access policy of a workload to a NetworkPolicy, and a FQDNNetworkPolicy
+// if the workload may reach external hosts. The workload is identified by its cluster, namespace and name.
+//
+// The NetworkPolicy denies all traffic that is not allowed by the access policy or the baseline. Rules for other clusters are left out,
+// as they are enforced by other means. Wildcards select all applications or namespaces, and team selectors
+// select pods with the team label. The output does not depend on the order of rules in the access policy.
+func NetworkPolicies(policy *nais_io_v1.AccessPolicy, workload Peer, baseline Baseline) (*networkingv1.NetworkPolicy, *fqdn.FQDNNetworkPolicy, error) {
+	if policy == nil {
+		policy = &nais_io_v1.AccessPolicy{}
+	}
+
+	name, err := policyName(workload.Application)
+	if err != nil {
+		return nil, nil, err
+	}
+	np := &networkingv1.NetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{Kind: "NetworkPolicy", APIVersion: networkingv1.SchemeGroupVersion.String()},
+		ObjectMeta: objectMeta(name, workload),
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: podSelector(workload),
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		},
+	}
+
+	var external []nais_io_v1.AccessPolicyExternalRule
+	if policy.Inbound != nil {
+		if peers := networkPolicyPeers(policy.Inbound.Rules, workload); len(peers) > 0 {
+			np.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{{From: peers}}
+		}
+	}
+	if policy.Outbound != nil {
+		if peers := networkPolicyPeers(policy.Outbound.Rules, workload); len(peers) > 0 {
+			np.Spec.Egress = []networkingv1.NetworkPolicyEgressRule{{To: peers}}
+		}
+		external = sortedExternalRules(policy.Outbound.External)
+	}
+	np.Spec.Ingress = append(np.Spec.Ingress, baseline.ingress()...)
+	np.Spec.Egress = append(np.Spec.Egress, baseline.egress()...)
+
+	fqdnRules, ipRules, err := ExternalEgress(external)
+	if err != nil {
+		return nil, nil, err
+	}
+	np.Spec.Egress = append(np.Spec.Egress, ipRules...)
+	if len(fqdnRules) == 0 {
+		return np, nil, nil
+	}
+
+	name, err = policyName(workload.Application + "-" + FQDNSuffix)
+	if err != nil {
+		return nil, nil, err
+	}
+	fqdnPolicy := &fqdn.FQDNNetworkPolicy{
+		TypeMeta:   metav1.TypeMeta{Kind: "FQDNNetworkPolicy", APIVersion: fqdn.GroupVersion.String()},
+		ObjectMeta: objectMeta(name, workload),
+		Spec: fqdn.FQDNNetworkPolicySpec{
+			PodSelector: podSelector(workload),
+			Egress:      fqdnRules,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+		},
+	}
+	return np, fqdnPolicy, nil
+}
+
+// ingress returns a rule for the ingress controllers and one for Prometheus, if they are set.
+func (in Baseline) ingress() []networkingv1.NetworkPolicyIngressRule {
+	var rules []networkingv1.NetworkPolicyIngressRule
+	for _, peers := range [][]networkingv1.NetworkPolicyPeer{in.IngressControllers, in.Prometheus} {
+		if len(peers) > 0 {
+			rules = append(rules, networkingv1.NetworkPolicyIngressRule{From: slices.Clone(peers)})
+		}
+	}
+	return rules
+}
+
+// egress returns a rule for DNS lookups, if DNS is set.
+func (in Baseline) egress() []networkingv1.NetworkPolicyEgressRule {
+	if len(in.DNS) == 0 {
+		return nil
+	}
+	return []networkingv1.NetworkPolicyEgressRule{{
+		Ports: NetworkPolicyPorts([]nais_io_v1.AccessPolicyPortRule{
+			{Port: DNSPort, Protocol: corev1.ProtocolUDP},
+			{Port: DNSPort, Protocol: corev1.ProtocolTCP},
+		}),
+		To: slices.Clone(in.DNS),
+	}}
+}
+
+// policyName returns the name as is if it is short enough, and a shortened name with a hash of the full name if not.
+func policyName(name string) (string, error) {
+	if len(name) <= validation.DNS1035LabelMaxLength {
+		return name, nil
+	}
+	return namegen.ShortName(name, validation.DNS1035LabelMaxLength)
+}
+
+func objectMeta(name string, workload Peer) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: workload.Namespace,
+		Labels:    map[string]string{AppLabel: workload.Application},
+	}
+}
+
+func podSelector(workload Peer) metav1.LabelSelector {
+	return metav1.LabelSelector{MatchLabels: map[string]string{AppLabel: workload.Application}}
+}
+
+// networkPolicyPeers returns a peer for every distinct rule for the cluster of the workload, sorted by namespace, application and team.
+func networkPolicyPeers(rules nais_io_v1.AccessPolicyBaseRules, workload Peer) []networkingv1.NetworkPolicyPeer {
+	var selected []nais_io_v1.AccessPolicyRule
+	for _, rule := range rules.GetRules() {
+		if rule.MatchesCluster(workload.Cluster) {
+			rule := rule.WithNamespace(workload.Namespace)
+			rule.Cluster = ""
+			selected = append(selected, rule)
+		}
+	}
+
+	slices.SortFunc(selected, func(a, b nais_io_v1.AccessPolicyRule) int {
+		return cmp.Or(
+			strings.Compare(a.Namespace, b.Namespace),
+			strings.Compare(a.Application, b.Application),
+			strings.Compare(a.Team, b.Team),
+		)
+	})
+	selected = slices.Compact(selected)
+
+	peers := make([]networkingv1.NetworkPolicyPeer, len(selected))
+	for i, rule := range selected {
+		peers[i] = networkPolicyPeer(rule)
+	}
+	return peers
+}
+
+func networkPolicyPeer(rule nais_io_v1.AccessPolicyRule) networkingv1.NetworkPolicyPeer {
+	pods := &metav1.LabelSelector{}
+	if rule.Application != nais_io_v1.AccessPolicyWildcard {
+		pods.MatchLabels = map[string]string{AppLabel: rule.Application}
+	}
+	if len(rule.Team) > 0 {
+		if pods.MatchLabels == nil {
+			pods.MatchLabels = map[string]string{}
+		}
+		pods.MatchLabels[nais_io_v1.TeamLabel] = rule.Team
+	}
+
+	namespaces := &metav1.LabelSelector{}
+	if rule.Namespace != nais_io_v1.AccessPolicyWildcard {
+		namespaces.MatchLabels = map[string]string{NamespaceLabel: rule.Namespace}
+	}
+
+	return networkingv1.NetworkPolicyPeer{PodSelector: pods, NamespaceSelector: namespaces}
+}
+
+// sortedExternalRules returns a copy of the rules with ports sorted by protocol and number,
+// sorted by their target and then by their ports.
+func sortedExternalRules(rules []nais_io_v1.AccessPolicyExternalRule) []nais_io_v1.AccessPolicyExternalRule {
+	comparePorts := func(a, b nais_io_v1.AccessPolicyPortRule) int {
+		return cmp.Or(strings.Compare(string(portProtocol(a)), string(portProtocol(b))), cmp.Compare(a.Port, b.Port))
+	}
+
+	sorted := make([]nais_io_v1.AccessPolicyExternalRule, len(rules))
+	for i, rule := range rules {
+		rule.Ports = slices.Clone(rule.Ports)
+		slices.SortFunc(rule.Ports, comparePorts)
+		sorted[i] = rule
+	}
+
+	// Only one target is set in each rule
+	target := func(rule nais_io_v1.AccessPolicyExternalRule) string {
+		return rule.Host + rule.IPv4 + rule.IPv6 + rule.CIDR
+	}
+	slices.SortFunc(sorted, func(a, b nais_io_v1.AccessPolicyExternalRule) int {
+		return cmp.Or(
+			strings.Compare(target(a), target(b)),
+			slices.CompareFunc(a.Ports, b.Ports, comparePorts),
+		)
+	})
+	return sorted
+}
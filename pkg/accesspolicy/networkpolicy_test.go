@@ -0,0 +1,206 @@
+package accesspolicy_test
+
+import (
+	"flag"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/nais/liberator/pkg/accesspolicy"
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+var baseline = accesspolicy.Baseline{
+	DNS: []networkingv1.NetworkPolicyPeer{accesspolicy.KubeDNS},
+	IngressControllers: []networkingv1.NetworkPolicyPeer{{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{accesspolicy.NamespaceLabel: "nais-system"}},
+		PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "ingress-nginx"}},
+	}},
+	Prometheus: []networkingv1.NetworkPolicyPeer{{
+		NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{accesspolicy.NamespaceLabel: "nais-system"}},
+		PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app.kubernetes.io/name": "prometheus"}},
+	}},
+}
+
+var networkPolicyTests = map[string]struct {
+	policy   *nais_io_v1.AccessPolicy
+	workload accesspolicy.Peer
+	baseline accesspolicy.Baseline
+}{
+	"no-policy": {
+		workload: peer("team-a", "api"),
+	},
+	"rules": {
+		policy: &nais_io_v1.AccessPolicy{
+			Inbound: &nais_io_v1.AccessPolicyInbound{Rules: []nais_io_v1.AccessPolicyInboundRule{
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "frontend"}},
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "batch", Namespace: "team-b", Cluster: cluster}},
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "remote", Cluster: "prod"}},
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "frontend", Namespace: "team-a"}},
+			}},
+			Outbound: &nais_io_v1.AccessPolicyOutbound{Rules: []nais_io_v1.AccessPolicyRule{
+				{Application: "database-proxy"},
+				{Application: "auth", Namespace: "platform"},
+			}},
+		},
+		workload: peer("team-a", "api"),
+	},
+	"wildcards": {
+		policy: &nais_io_v1.AccessPolicy{
+			Inbound: &nais_io_v1.AccessPolicyInbound{Rules: []nais_io_v1.AccessPolicyInboundRule{
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "*", Namespace: "*", Team: "team-b"}},
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "*"}},
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "monitor", Namespace: "*"}},
+			}},
+			Outbound: &nais_io_v1.AccessPolicyOutbound{Rules: []nais_io_v1.AccessPolicyRule{
+				{Application: "*", Namespace: "platform"},
+			}},
+		},
+		workload: peer("team-a", "api"),
+	},
+	"external": {
+		policy: &nais_io_v1.AccessPolicy{
+			Outbound: &nais_io_v1.AccessPolicyOutbound{External: []nais_io_v1.AccessPolicyExternalRule{
+				{Host: "example.com"},
+				{Host: "example.com", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 8443}}},
+				{CIDR: "10.0.0.0/8", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 5432}}},
+				{CIDR: "10.0.0.0/8", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 5432, Protocol: "UDP"}}},
+				{Host: "dns.example.org", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 53, Protocol: "UDP"}, {Port: 53}}},
+				{IPv6: "2001:db8::1"},
+				{IPv4: "192.168.1.1", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 8443}, {Port: 443}}},
+			}},
+		},
+		workload: peer("team-a", "api"),
+	},
+	"baseline": {
+		policy: &nais_io_v1.AccessPolicy{
+			Inbound: &nais_io_v1.AccessPolicyInbound{Rules: []nais_io_v1.AccessPolicyInboundRule{
+				{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "frontend"}},
+			}},
+			Outbound: &nais_io_v1.AccessPolicyOutbound{
+				Rules:    []nais_io_v1.AccessPolicyRule{{Application: "database-proxy"}},
+				External: []nais_io_v1.AccessPolicyExternalRule{{CIDR: "10.0.0.0/8"}},
+			},
+		},
+		workload: peer("team-a", "api"),
+		baseline: baseline,
+	},
+	"baseline-no-policy": {
+		workload: peer("team-a", "api"),
+		baseline: baseline,
+	},
+	"long-name": {
+		policy: &nais_io_v1.AccessPolicy{
+			Outbound: &nais_io_v1.AccessPolicyOutbound{External: []nais_io_v1.AccessPolicyExternalRule{
+				{Host: "example.com"},
+			}},
+		},
+		workload: peer("team-a", "an-application-with-a-name-that-is-just-a-bit-too-long-for-it"),
+	},
+}
+
+func TestNetworkPolicies(t *testing.T) {
+	for name, tt := range networkPolicyTests {
+		t.Run(name, func(t *testing.T) {
+			np, fqdnPolicy, err := accesspolicy.NetworkPolicies(tt.policy, tt.workload, tt.baseline)
+			require.NoError(t, err)
+
+			documents := make([]string, 0, 2)
+			for _, obj := range []any{np, fqdnPolicy} {
+				if obj == fqdnPolicy && fqdnPolicy == nil {
+					continue
+				}
+				data, err := yaml.Marshal(obj)
+				require.NoError(t, err)
+				documents = append(documents, string(data))
+			}
+			actual := strings.Join(documents, "---\n")
+
+			golden := filepath.Join("testdata", name+".golden.yaml")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, []byte(actual), 0o644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err, "run with -update to create the golden file")
+			assert.Equal(t, string(expected), actual)
+		})
+	}
+}
+
+func TestNetworkPolicies_Order(t *testing.T) {
+	for name, tt := range networkPolicyTests {
+		if tt.policy == nil {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			reversed := &nais_io_v1.AccessPolicy{}
+			if in := tt.policy.Inbound; in != nil {
+				reversed.Inbound = &nais_io_v1.AccessPolicyInbound{Rules: slices.Clone(in.Rules)}
+				slices.Reverse(reversed.Inbound.Rules)
+			}
+			if out := tt.policy.Outbound; out != nil {
+				reversed.Outbound = &nais_io_v1.AccessPolicyOutbound{Rules: slices.Clone(out.Rules), External: slices.Clone(out.External)}
+				slices.Reverse(reversed.Outbound.Rules)
+				slices.Reverse(reversed.Outbound.External)
+				for i := range reversed.Outbound.External {
+					ports := slices.Clone(reversed.Outbound.External[i].Ports)
+					slices.Reverse(ports)
+					reversed.Outbound.External[i].Ports = ports
+				}
+			}
+
+			np, fqdnPolicy, err := accesspolicy.NetworkPolicies(tt.policy, tt.workload, tt.baseline)
+			require.NoError(t, err)
+			reversedNP, reversedFQDNPolicy, err := accesspolicy.NetworkPolicies(reversed, tt.workload, tt.baseline)
+			require.NoError(t, err)
+			assert.Equal(t, np, reversedNP)
+			assert.Equal(t, fqdnPolicy, reversedFQDNPolicy)
+		})
+	}
+}
+
+func TestNetworkPolicies_Shuffle(t *testing.T) {
+	external := []nais_io_v1.AccessPolicyExternalRule{
+		{Host: "example.com"},
+		{Host: "example.com", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 8443}}},
+		{Host: "example.com", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 8443, Protocol: "UDP"}}},
+		{Host: "example.com", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 443}, {Port: 8443}}},
+		{CIDR: "10.0.0.0/8", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 5432}}},
+		{CIDR: "10.0.0.0/8", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 53, Protocol: "UDP"}, {Port: 53}}},
+		{CIDR: "10.0.0.0/8", Ports: []nais_io_v1.AccessPolicyPortRule{{Port: 53}}},
+	}
+	policy := func(external []nais_io_v1.AccessPolicyExternalRule) *nais_io_v1.AccessPolicy {
+		return &nais_io_v1.AccessPolicy{Outbound: &nais_io_v1.AccessPolicyOutbound{External: external}}
+	}
+
+	np, fqdnPolicy, err := accesspolicy.NetworkPolicies(policy(external), peer("team-a", "api"), accesspolicy.Baseline{})
+	require.NoError(t, err)
+
+	random := rand.New(rand.NewPCG(1, 2))
+	for i := range 20 {
+		shuffled := slices.Clone(external)
+		random.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		shuffledNP, shuffledFQDNPolicy, err := accesspolicy.NetworkPolicies(policy(shuffled), peer("team-a", "api"), accesspolicy.Baseline{})
+		require.NoError(t, err)
+		assert.Equal(t, np, shuffledNP, "shuffle %d: %v", i, shuffled)
+		assert.Equal(t, fqdnPolicy, shuffledFQDNPolicy, "shuffle %d: %v", i, shuffled)
+	}
+}
+
+func TestNetworkPolicies_Invalid(t *testing.T) {
+	_, _, err := accesspolicy.NetworkPolicies(&nais_io_v1.AccessPolicy{
+		Outbound: &nais_io_v1.AccessPolicyOutbound{External: []nais_io_v1.AccessPolicyExternalRule{{CIDR: "10.0.0.1/8"}}},
+	}, peer("team-a", "api"), accesspolicy.Baseline{})
+	assert.ErrorContains(t, err, "external rule 0")
+}
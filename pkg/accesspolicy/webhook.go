@@ -0,0 +1,137 @@
+package accesspolicy
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	nais_io_v1alpha1 "github.com/nais/liberator/pkg/apis/nais.io/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// WithWarnings warns about access policy rules that do not allow any traffic,
+// because the workload they refer to does not exist, or does not have a matching rule in the other direction.
+// Only rules for workloads in the given cluster are checked.
+//
+// Workloads are read through the client of the validators, which must have Applications and Naisjobs in its scheme.
+// With the client of a manager, this requires permission to list and watch Applications and Naisjobs in all namespaces.
+func WithWarnings(cluster string) nais_io_v1.ValidatorOption {
+	return func(cfg *nais_io_v1.ValidatorConfig) {
+		cfg.ClusterName = cluster
+		cfg.Checks = append(cfg.Checks, checkWarnings)
+	}
+}
+
+// checkWarnings warns about one-sided access policy rules.
+// Failing to look up workloads is not fatal, as the warnings are only advisory.
+func checkWarnings(ctx context.Context, req *nais_io_v1.WorkloadRequest) (admission.Warnings, error) {
+	warnings, err := Warnings(ctx, req.Client, req.Config.ClusterName, req.Object, field.NewPath("spec", "accessPolicy"))
+	if err != nil {
+		req.Logger.Error(err, "unable to check access policy", strings.ToLower(req.Kind), req.Object.GetName(), "namespace", req.Object.GetNamespace())
+	}
+	return warnings, nil
+}
+
+// Warnings returns a warning for every outbound rule whose target does not have an inbound rule for the given workload,
+// and for every rule that refers to a workload that does not exist. Traffic is only allowed when both rules exist.
+//
+// The graph is built from the given workload and the workloads its rules refer to, so rules with wildcards
+// or team selectors are not checked.
+func Warnings(ctx context.Context, reader client.Reader, cluster string, obj Workload, path *field.Path) (admission.Warnings, error) {
+	policy := obj.GetAccessPolicy()
+	if len(cluster) == 0 || policy == nil {
+		return nil, nil
+	}
+
+	empty := New(cluster)
+	self := empty.Peer(obj)
+	checked := func(rule nais_io_v1.AccessPolicyRule) bool {
+		return rule.MatchesCluster(cluster) && !rule.IsSelector()
+	}
+
+	workloads := []Workload{obj}
+	seen := map[Peer]bool{self: true}
+	for _, rule := range policyRules(policy) {
+		peer := empty.Resolve(self, rule)
+		if !checked(rule) || seen[peer] {
+			continue
+		}
+		seen[peer] = true
+
+		found, err := lookup(ctx, reader, client.ObjectKey{Namespace: peer.Namespace, Name: peer.Application})
+		if err != nil {
+			return nil, err
+		}
+		workloads = append(workloads, found...)
+	}
+
+	g := New(cluster, workloads...)
+	oneSided, dangling := g.OneSided(), g.Dangling()
+
+	var warnings admission.Warnings
+	warn := func(direction Direction, rules []nais_io_v1.AccessPolicyRule, path *field.Path) {
+		for i, rule := range rules {
+			if !checked(rule) {
+				continue
+			}
+			resolved := Rule{Owner: self, Direction: direction, Peer: g.Resolve(self, rule), Team: rule.Team}
+			target := fmt.Sprintf("%s/%s", resolved.Peer.Namespace, resolved.Peer.Application)
+			switch {
+			case slices.Contains(dangling, resolved):
+				warnings = append(warnings, fmt.Sprintf("%s: %s does not exist", path.Index(i), target))
+			case direction == Outbound && slices.Contains(oneSided, resolved):
+				warnings = append(warnings, fmt.Sprintf("%s: %s does not allow inbound traffic from %s/%s; add it to spec.accessPolicy.inbound.rules of %s", path.Index(i), target, self.Namespace, self.Application, target))
+			}
+		}
+	}
+	if policy.Outbound != nil {
+		warn(Outbound, policy.Outbound.Rules.GetRules(), path.Child("outbound", "rules"))
+	}
+	if policy.Inbound != nil {
+		warn(Inbound, policy.Inbound.Rules.GetRules(), path.Child("inbound", "rules"))
+	}
+	return warnings, nil
+}
+
+// policyRules returns the inbound and outbound rules of an access policy.
+func policyRules(policy *nais_io_v1.AccessPolicy) []nais_io_v1.AccessPolicyRule {
+	var rules []nais_io_v1.AccessPolicyRule
+	if policy.Inbound != nil {
+		rules = append(rules, policy.Inbound.Rules.GetRules()...)
+	}
+	if policy.Outbound != nil {
+		rules = append(rules, policy.Outbound.Rules.GetRules()...)
+	}
+	return rules
+}
+
+// lookup returns the Application and Naisjob with the given name, if they exist.
+// Clusters without Applications or Naisjobs are treated as having none.
+func lookup(ctx context.Context, reader client.Reader, key client.ObjectKey) ([]Workload, error) {
+	var workloads []Workload
+	for _, kind := range []struct {
+		name string
+		obj  interface {
+			client.Object
+			Workload
+		}
+	}{
+		{"Application", &nais_io_v1alpha1.Application{}},
+		{"Naisjob", &nais_io_v1.Naisjob{}},
+	} {
+		if err := reader.Get(ctx, key, kind.obj); err != nil {
+			if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+				continue
+			}
+			return nil, fmt.Errorf("get %s %s: %w", kind.name, key, err)
+		}
+		workloads = append(workloads, kind.obj)
+	}
+	return workloads, nil
+}
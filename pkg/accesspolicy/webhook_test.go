@@ -0,0 +1,137 @@
+package accesspolicy_test
+
+import (
+	"testing"
+
+	"github.com/nais/liberator/pkg/accesspolicy"
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	nais_io_v1alpha1 "github.com/nais/liberator/pkg/apis/nais.io/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func fakeKubeClient(objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	_ = nais_io_v1.AddToScheme(scheme)
+	_ = nais_io_v1alpha1.AddToScheme(scheme)
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func jobValidator(objs ...client.Object) *nais_io_v1.WorkloadValidator[*nais_io_v1.Naisjob] {
+	return &nais_io_v1.WorkloadValidator[*nais_io_v1.Naisjob]{
+		Client: fakeKubeClient(objs...),
+		Config: nais_io_v1.NewValidatorConfig(accesspolicy.WithWarnings("dev-gcp")),
+		Kind:   "Naisjob",
+		Spec:   func(nj *nais_io_v1.Naisjob) any { return nj.Spec },
+	}
+}
+
+func scheduled(nj *nais_io_v1.Naisjob) *nais_io_v1.Naisjob {
+	nj.Spec.Image = "nginx:latest"
+	nj.Spec.Schedule = "0 * * * *"
+	return nj
+}
+
+func TestWithWarnings(t *testing.T) {
+	nj := scheduled(naisjob("test-ns", "test-job", policy(
+		rules(
+			nais_io_v1.AccessPolicyRule{Application: "caller"},
+			nais_io_v1.AccessPolicyRule{Application: "remote", Cluster: "prod-gcp"},
+		),
+		rules(nais_io_v1.AccessPolicyRule{Application: "target", Namespace: "other-ns"}),
+	)))
+	oneSided := "spec.accessPolicy.outbound.rules[0]: other-ns/target does not allow inbound traffic from test-ns/test-job; add it to spec.accessPolicy.inbound.rules of other-ns/target"
+
+	t.Run("no workloads", func(t *testing.T) {
+		warnings, err := jobValidator().ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Equal(t, admission.Warnings{
+			"spec.accessPolicy.outbound.rules[0]: other-ns/target does not exist",
+			"spec.accessPolicy.inbound.rules[0]: test-ns/caller does not exist",
+		}, warnings)
+	})
+
+	t.Run("target without inbound rule", func(t *testing.T) {
+		warnings, err := jobValidator(
+			naisjob("test-ns", "caller", nil),
+			naisjob("other-ns", "target", policy(rules(nais_io_v1.AccessPolicyRule{Application: "test-job"}), nil)),
+		).ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Equal(t, admission.Warnings{oneSided}, warnings)
+	})
+
+	t.Run("target with inbound rule for another cluster", func(t *testing.T) {
+		warnings, err := jobValidator(
+			naisjob("test-ns", "caller", nil),
+			naisjob("other-ns", "target", policy(rules(nais_io_v1.AccessPolicyRule{Application: "test-job", Namespace: "test-ns", Cluster: "prod-gcp"}), nil)),
+		).ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Equal(t, admission.Warnings{oneSided}, warnings)
+	})
+
+	t.Run("target allows all applications in the namespace", func(t *testing.T) {
+		warnings, err := jobValidator(
+			naisjob("test-ns", "caller", nil),
+			naisjob("other-ns", "target", policy(rules(nais_io_v1.AccessPolicyRule{Application: "*", Namespace: "test-ns"}), nil)),
+		).ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("target allows another team", func(t *testing.T) {
+		warnings, err := jobValidator(
+			naisjob("test-ns", "caller", nil),
+			naisjob("other-ns", "target", policy(rules(nais_io_v1.AccessPolicyRule{Application: "*", Namespace: "*", Team: "other-team"}), nil)),
+		).ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Equal(t, admission.Warnings{oneSided}, warnings)
+	})
+
+	t.Run("both sides", func(t *testing.T) {
+		warnings, err := jobValidator(
+			application("test-ns", "caller", nil),
+			application("other-ns", "target", policy(rules(nais_io_v1.AccessPolicyRule{Application: "test-job", Namespace: "test-ns", Cluster: "dev-gcp"}), nil)),
+		).ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("target is both an application and a naisjob", func(t *testing.T) {
+		warnings, err := jobValidator(
+			naisjob("test-ns", "caller", nil),
+			application("other-ns", "target", nil),
+			naisjob("other-ns", "target", policy(rules(nais_io_v1.AccessPolicyRule{Application: "test-job", Namespace: "test-ns"}), nil)),
+		).ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("applications are checked", func(t *testing.T) {
+		validator := &nais_io_v1.WorkloadValidator[*nais_io_v1alpha1.Application]{
+			Client: fakeKubeClient(
+				application("other-ns", "target", policy(rules(nais_io_v1.AccessPolicyRule{Application: "someone-else", Namespace: "test-ns"}), nil)),
+			),
+			Config: nais_io_v1.NewValidatorConfig(accesspolicy.WithWarnings("dev-gcp")),
+			Kind:   "Application",
+			Spec:   func(a *nais_io_v1alpha1.Application) any { return a.Spec },
+		}
+		app := application("test-ns", "test-app", policy(nil, rules(nais_io_v1.AccessPolicyRule{Application: "target", Namespace: "other-ns"})))
+		app.Spec.Image = "nginx:latest"
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		require.NoError(t, err)
+		assert.Equal(t, admission.Warnings{"spec.accessPolicy.outbound.rules[0]: other-ns/target does not allow inbound traffic from test-ns/test-app; add it to spec.accessPolicy.inbound.rules of other-ns/target"}, warnings)
+	})
+
+	t.Run("without the option", func(t *testing.T) {
+		validator := jobValidator()
+		validator.Config = nais_io_v1.NewValidatorConfig()
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+}
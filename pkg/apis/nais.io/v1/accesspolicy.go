@@ -1,28 +1,80 @@
 package nais_io_v1
 
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
 type AccessPolicyPortRule struct {
 	// The port used for communication.
 	Port uint32 `json:"port"`
+	// The protocol used for communication. Defaults to TCP.
+	// +kubebuilder:validation:Enum=TCP;UDP
+	Protocol corev1.Protocol `json:"protocol,omitempty"`
 }
 
 type AccessPolicyExternalRule struct {
-	// The _host_ that your application should be able to reach, i.e. without the protocol (e.g. `https://`). "Host" and "IPv4" are mutually exclusive
+	// The _host_ that your application should be able to reach, i.e. without the protocol (e.g. `https://`). Wildcards are not supported. Exactly one of "Host", "IPv4", "IPv6" and "CIDR" must be set
 	// +kubebuilder:validation:Pattern=`^([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9\-]{0,61}[a-zA-Z0-9])(\.([a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9\-]{0,61}[a-zA-Z0-9]))*$`
-	Host string `json:"host,omitempty"`
-	// The IPv4 address that your application should be able to reach. "IPv4" and "Host" are mutually exclusive
+	Host string `json:"host,omitempty" nais:"oneOf=target"`
+	// The IPv4 address that your application should be able to reach. Exactly one of "IPv4", "IPv6", "CIDR" and "Host" must be set
 	// +kubebuilder:validation:Pattern=`^(([0-9])|([1-9][0-9])|(1([0-9]{2}))|(2[0-4][0-9])|(25[0-5]))((\.(([0-9])|([1-9][0-9])|(1([0-9]{2}))|(2[0-4][0-9])|(25[0-5]))){3})$`
-	IPv4 string `json:"ipv4,omitempty"`
+	IPv4 string `json:"ipv4,omitempty" nais:"oneOf=target"`
+	// The IPv6 address that your application should be able to reach. Exactly one of "IPv6", "IPv4", "CIDR" and "Host" must be set
+	IPv6 string `json:"ipv6,omitempty" nais:"oneOf=target"`
+	// The IPv4 or IPv6 network that your application should be able to reach, in CIDR notation (e.g. `10.0.0.0/8`). Exactly one of "CIDR", "IPv4", "IPv6" and "Host" must be set
+	CIDR string `json:"cidr,omitempty" nais:"oneOf=target"`
 	// List of port rules for external communication. Must be specified if using protocols other than HTTPS.
 	Ports []AccessPolicyPortRule `json:"ports,omitempty"`
 }
 
+// Prefix returns the network that the rule allows traffic to; a single address for IPv4 and IPv6 rules, or the CIDR range.
+// The second return value is false for rules with a host.
+func (in AccessPolicyExternalRule) Prefix() (netip.Prefix, bool, error) {
+	switch {
+	case len(in.IPv4) > 0:
+		addr, err := netip.ParseAddr(in.IPv4)
+		if err != nil || !addr.Is4() {
+			return netip.Prefix{}, true, errors.New("not an IPv4 address")
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), true, nil
+	case len(in.IPv6) > 0:
+		addr, err := netip.ParseAddr(in.IPv6)
+		if err != nil || !addr.Is6() || addr.Is4In6() || len(addr.Zone()) > 0 {
+			return netip.Prefix{}, true, errors.New("not an IPv6 address")
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), true, nil
+	case len(in.CIDR) > 0:
+		prefix, err := netip.ParsePrefix(in.CIDR)
+		if err != nil {
+			return netip.Prefix{}, true, errors.New("not a network in CIDR notation, e.g. 10.0.0.0/8")
+		}
+		if prefix != prefix.Masked() {
+			return netip.Prefix{}, true, fmt.Errorf("not a network address; did you mean %s?", prefix.Masked())
+		}
+		return prefix, true, nil
+	}
+	return netip.Prefix{}, false, nil
+}
+
+// AccessPolicyWildcard matches any application or namespace in an AccessPolicyRule.
+const AccessPolicyWildcard = "*"
+
 type AccessPolicyRule struct {
-	// The application's name.
+	// The application's name, or `*` to match all applications in the namespace.
+	// Wildcards are only supported for Applications and Naisjobs.
 	Application string `json:"application"`
-	// The application's namespace. May be omitted if it should be in the same namespace as your application.
+	// The application's namespace. May be omitted if it should be in the same namespace as your application, or `*` to match all namespaces.
+	// Wildcards are only supported for Applications and Naisjobs.
 	Namespace string `json:"namespace,omitempty"`
 	// The application's cluster. May be omitted if it should be in the same cluster as your application.
 	Cluster string `json:"cluster,omitempty"`
+	// Only match applications owned by this team, i.e. with this value in the `team` label.
+	// Only supported for Applications and Naisjobs.
+	Team string `json:"team,omitempty"`
 }
 
 // +k8s:deepcopy-gen=false
@@ -94,3 +146,39 @@ func (in AccessPolicyRule) MatchesCluster(clusterName string) bool {
 	}
 	return true
 }
+
+// WithNamespace returns the rule with the given namespace, if the rule does not specify one.
+// Rules without a namespace refer to the namespace of the workload that has the rule.
+func (in AccessPolicyRule) WithNamespace(namespace string) AccessPolicyRule {
+	if len(in.Namespace) == 0 {
+		in.Namespace = namespace
+	}
+	return in
+}
+
+// Matches returns true if the rule refers to the given application.
+// Rules without a namespace must be given one using WithNamespace first.
+// Team selectors are not considered; use MatchesTeam.
+func (in AccessPolicyRule) Matches(clusterName, namespace, application string) bool {
+	return in.MatchesCluster(clusterName) && matchesName(in.Namespace, namespace) && matchesName(in.Application, application)
+}
+
+// MatchesTeam returns true if the rule does not select a team, or selects the given team.
+func (in AccessPolicyRule) MatchesTeam(team string) bool {
+	return len(in.Team) == 0 || in.Team == team
+}
+
+// HasWildcard returns true if the rule matches all applications, or all namespaces.
+func (in AccessPolicyRule) HasWildcard() bool {
+	return in.Application == AccessPolicyWildcard || in.Namespace == AccessPolicyWildcard
+}
+
+// IsSelector returns true if the rule may match more or fewer applications than the one it names,
+// i.e. if it has a wildcard or selects a team.
+func (in AccessPolicyRule) IsSelector() bool {
+	return in.HasWildcard() || len(in.Team) > 0
+}
+
+func matchesName(pattern, name string) bool {
+	return pattern == AccessPolicyWildcard || pattern == name
+}
@@ -65,7 +65,8 @@ type AzureAdApplicationSpec struct {
 	GroupMembershipClaims *string `json:"groupMembershipClaims,omitempty"`
 	// LogoutUrl is the URL where Azure AD sends a request to have the application clear the user's session data.
 	// This is required if single sign-out should work correctly. Must start with 'https'
-	LogoutUrl                 string                    `json:"logoutUrl,omitempty"`
+	LogoutUrl string `json:"logoutUrl,omitempty"`
+	// +kubebuilder:validation:XValidation:rule="self.all(x, x.application != '*' && (!has(x.namespace) || x.namespace != '*') && !has(x.team))",message="wildcards and team selectors are only supported in the access policy of Applications and Naisjobs"
 	PreAuthorizedApplications []AccessPolicyInboundRule `json:"preAuthorizedApplications,omitempty"`
 	ReplyUrls                 []AzureAdReplyUrl         `json:"replyUrls,omitempty"`
 	// SecretName is the name of the resulting Secret resource to be created
@@ -78,7 +79,7 @@ type AzureAdApplicationSpec struct {
 	// Can be omitted if only running a single instance or targeting the default tenant.
 	// Immutable once set.
 	// +kubebuilder:validation:Optional
-	Tenant string `json:"tenant,omitempty"`
+	Tenant string `json:"tenant,omitempty" nais:"immutable,onceset"`
 }
 
 // AzureAdApplicationStatus defines the observed state of AzureAdApplication
@@ -139,7 +140,7 @@ type AzureAdPreAuthorizedApp struct {
 type AzureAdClaims struct {
 	// Deprecated. These claims are already included by default; this field is ignored and has no effect. It will be removed in a future release.
 	// +nais:doc:Deprecated=true
-	Extra []AzureAdExtraClaim `json:"extra,omitempty"`
+	Extra []AzureAdExtraClaim `json:"extra,omitempty" nais:"deprecated"`
 	// Groups is a list of Azure AD group IDs to be emitted in the `groups` claim in tokens issued by Azure AD.
 	// This also assigns groups to the application for access control. Only direct members of the groups are granted access.
 	// +nais:doc:Link="https://doc.nais.io/auth/entra-id/reference/#groups"
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"github.com/nais/liberator/pkg/webhookvalidator"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -39,3 +40,7 @@ func minimalApplication() *nais_io_v1.AzureAdApplication {
 		},
 	}
 }
+
+func TestAzureAdApplicationSpec_Lint(t *testing.T) {
+	assert.NoError(t, webhookvalidator.Lint(nais_io_v1.AzureAdApplicationSpec{}))
+}
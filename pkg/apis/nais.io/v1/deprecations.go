@@ -0,0 +1,94 @@
+package nais_io_v1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nais/liberator/pkg/webhookvalidator"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Deprecation describes a spec field marked with `+nais:doc:Deprecated=true`, and how to migrate away from it.
+// +kubebuilder:object:generate=false
+type Deprecation struct {
+	// Message explains why the field is deprecated and what to use instead.
+	Message string
+	// EndOfLife is the date the field will stop working, or the zero value if it has not been decided.
+	EndOfLife time.Time
+}
+
+// Deprecations is the catalogue of deprecated spec fields, keyed by the Go name of the field
+// qualified with the name of the type containing it.
+//
+// Every field in the catalogue must be tagged with `nais:"deprecated"`.
+var Deprecations = map[string]Deprecation{
+	"ApplicationSpec.PreStopHookPath": {
+		Message: "use spec.preStopHook.http.path instead",
+	},
+	"AzureApplication.ReplyURLs": {
+		Message: "only needed when implementing logins without the login proxy; use spec.azure.sidecar instead",
+	},
+	"AzureApplication.SinglePageApplication": {
+		Message: "logins in client-side frontend applications are not recommended; use spec.azure.sidecar instead",
+	},
+	"AzureAdClaims.Extra": {
+		Message: "these claims are included by default and the field has no effect; remove it",
+	},
+	"Replicas.CpuThresholdPercentage": {
+		Message: "use spec.replicas.scalingStrategy.cpu.thresholdPercentage instead",
+	},
+	"Observability.Tracing": {
+		Message: "use spec.observability.autoInstrumentation instead",
+	},
+}
+
+// DeprecatedField is a deprecated field that is set in a spec.
+// +kubebuilder:object:generate=false
+type DeprecatedField struct {
+	// Path is the full JSON path of the field, e.g. `spec.azure.application.replyURLs`.
+	Path string
+	Deprecation
+}
+
+// DeprecatedFields returns all deprecated fields that are set in a spec.
+func DeprecatedFields(spec any, path *field.Path) []DeprecatedField {
+	found := webhookvalidator.Deprecated(spec, path)
+	fields := make([]DeprecatedField, 0, len(found))
+	for _, f := range found {
+		fields = append(fields, DeprecatedField{
+			Path:        f.Path.String(),
+			Deprecation: Deprecations[f.Name],
+		})
+	}
+	return fields
+}
+
+// Warning returns a human-readable warning suitable for admission responses and status problems.
+func (in DeprecatedField) Warning() string {
+	msg := fmt.Sprintf("%s is deprecated", in.Path)
+	if !in.EndOfLife.IsZero() {
+		msg += fmt.Sprintf(" and will stop working after %s", in.EndOfLife.Format(time.DateOnly))
+	}
+	if len(in.Message) > 0 {
+		msg += ": " + in.Message
+	}
+	return msg
+}
+
+// DeprecationWarnings returns an admission warning for each deprecated field that is set in a spec.
+func DeprecationWarnings(spec any, path *field.Path) admission.Warnings {
+	var warnings admission.Warnings
+	for _, f := range DeprecatedFields(spec, path) {
+		warnings = append(warnings, f.Warning())
+	}
+	return warnings
+}
+
+// AddDeprecations adds a problem for each deprecated field that is set in a spec,
+// using the same messages as DeprecationWarnings.
+func (in *Status) AddDeprecations(spec any, path *field.Path) {
+	for _, f := range DeprecatedFields(spec, path) {
+		in.AddDeprecation("."+f.Path, f.Warning(), f.EndOfLife)
+	}
+}
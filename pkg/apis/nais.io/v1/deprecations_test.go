@@ -0,0 +1,102 @@
+package nais_io_v1_test
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// TestDeprecations_MatchMarkers ensures that the deprecation catalogue contains exactly the fields
+// marked with `+nais:doc:Deprecated=true`, and that all of them are tagged with `nais:"deprecated"`.
+func TestDeprecations_MatchMarkers(t *testing.T) {
+	marked := map[string]bool{}
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	v1alpha1Files, err := filepath.Glob("../v1alpha1/*.go")
+	require.NoError(t, err)
+
+	fset := token.NewFileSet()
+	for _, path := range append(files, v1alpha1Files...) {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		require.NoError(t, err)
+
+		ast.Inspect(file, func(node ast.Node) bool {
+			spec, ok := node.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			for _, f := range st.Fields.List {
+				if f.Doc == nil || !strings.Contains(f.Doc.Text(), "+nais:doc:Deprecated=true") {
+					continue
+				}
+				tag := ""
+				if f.Tag != nil {
+					tag, _ = strconv.Unquote(f.Tag.Value)
+				}
+				for _, name := range f.Names {
+					qualified := spec.Name.Name + "." + name.Name
+					marked[qualified] = true
+					assert.Contains(t, reflect.StructTag(tag).Get("nais"), "deprecated", "%s must be tagged with `nais:\"deprecated\"`", qualified)
+				}
+			}
+			return false
+		})
+	}
+
+	for name := range marked {
+		assert.Contains(t, nais_io_v1.Deprecations, name, "%s is marked as deprecated, but is missing from the catalogue", name)
+	}
+	for name := range nais_io_v1.Deprecations {
+		assert.Contains(t, marked, name, "%s is in the catalogue, but is not marked with +nais:doc:Deprecated=true", name)
+	}
+}
+
+func TestStatus_AddDeprecations(t *testing.T) {
+	spec := nais_io_v1.NaisjobSpec{
+		Observability: &nais_io_v1.Observability{
+			Tracing: &nais_io_v1.Tracing{},
+		},
+	}
+
+	status := nais_io_v1.Status{}
+	status.AddDeprecations(spec, field.NewPath("spec"))
+	warnings := nais_io_v1.DeprecationWarnings(spec, field.NewPath("spec"))
+
+	require.NotNil(t, status.Problems)
+	problems := *status.Problems
+	require.Len(t, problems, 1)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, nais_io_v1.ProblemKindDeprecation, problems[0].Type)
+	assert.Equal(t, ".spec.observability.tracing", *problems[0].Source)
+	assert.Nil(t, problems[0].EndOfLife)
+	assert.Equal(t, warnings[0], problems[0].Message)
+}
+
+func TestDeprecatedField_Warning(t *testing.T) {
+	f := nais_io_v1.DeprecatedField{
+		Path: "spec.foo",
+		Deprecation: nais_io_v1.Deprecation{
+			Message:   "use spec.bar instead",
+			EndOfLife: time.Date(2027, time.January, 31, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	assert.Equal(t, "spec.foo is deprecated and will stop working after 2027-01-31: use spec.bar instead", f.Warning())
+}
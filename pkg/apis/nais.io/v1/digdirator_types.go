@@ -249,7 +249,7 @@ type IDPortenClientSpec struct {
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Enum=krr;idporten;api_klient
 	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="integrationType is immutable; delete and recreate the IDPortenClient to change integrationType"
-	IntegrationType string `json:"integrationType,omitempty" nais:"immutable"`
+	IntegrationType string `json:"integrationType,omitempty" nais:"immutable,strict"`
 	// FrontchannelLogoutURI is the URL that ID-porten sends a requests to whenever a logout is triggered by another application using the same session
 	FrontchannelLogoutURI IDPortenURI `json:"frontchannelLogoutURI,omitempty"`
 	// PostLogoutRedirectURI is a list of valid URIs that ID-porten may redirect to after logout
@@ -7,6 +7,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"github.com/nais/liberator/pkg/webhookvalidator"
 )
 
 func TestMaskinportenClient_CalculateHash(t *testing.T) {
@@ -71,3 +72,7 @@ func minimalMaskinportenExtendedClient() *nais_io_v1.MaskinportenClient {
 		},
 	}
 }
+
+func TestDigdiratorSpec_Lint(t *testing.T) {
+	assert.NoError(t, webhookvalidator.Lint(nais_io_v1.IDPortenClientSpec{}, nais_io_v1.MaskinportenClientSpec{}))
+}
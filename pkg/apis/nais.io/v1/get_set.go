@@ -61,6 +61,10 @@ func (in *Naisjob) GetImage() string {
 	return in.Spec.Image
 }
 
+func (in *Naisjob) GetResources() *ResourceRequirements {
+	return in.Spec.Resources
+}
+
 func (in *Naisjob) GetEffectiveImage() string {
 	return in.Status.EffectiveImage
 }
@@ -166,3 +170,7 @@ func (in *Naisjob) GetLogin() *Login {
 func (in *Naisjob) GetPostgres() *Postgres {
 	return in.Spec.Postgres
 }
+
+func (in *Naisjob) GetTTL() string {
+	return in.Spec.TTL
+}
@@ -13,6 +13,8 @@ func init() {
 }
 
 type JwkerSpec struct {
+	// +kubebuilder:validation:XValidation:rule="!has(self.inbound) || self.inbound.rules.all(x, x.application != '*' && (!has(x.namespace) || x.namespace != '*') && !has(x.team))",message="wildcards and team selectors are only supported in the access policy of Applications and Naisjobs"
+	// +kubebuilder:validation:XValidation:rule="!has(self.outbound) || !has(self.outbound.rules) || self.outbound.rules.all(x, x.application != '*' && (!has(x.namespace) || x.namespace != '*') && !has(x.team))",message="wildcards and team selectors are only supported in the access policy of Applications and Naisjobs"
 	AccessPolicy *AccessPolicy `json:"accessPolicy"` // fixme: access policy should not have rules required, but cluster and namespace. doesn't need external.
 	SecretName   string        `json:"secretName"`
 }
@@ -41,7 +41,7 @@ type AzureApplication struct {
 	Enabled bool `json:"enabled"`
 	// Deprecated, do not use. This is currently only needed if you're implementing logins _without_ using sidecar. This field will be removed in a future release.
 	// +nais:doc:Deprecated=true
-	ReplyURLs []AzureAdReplyUrlString `json:"replyURLs,omitempty"`
+	ReplyURLs []AzureAdReplyUrlString `json:"replyURLs,omitempty" nais:"deprecated"`
 	// Tenant targets a specific tenant for the Entra ID application.
 	//
 	// In production clusters, the value defaults to `nav.no` if unspecified; no other value is allowed.
@@ -55,12 +55,12 @@ type AzureApplication struct {
 	// +nais:doc:Tenants="nav"
 	// +kubebuilder:validation:Optional
 	// +kubebuilder:validation:Enum=nav.no;trygdeetaten.no
-	Tenant string         `json:"tenant,omitempty"`
+	Tenant string         `json:"tenant,omitempty" nais:"immutable,onceset"`
 	Claims *AzureAdClaims `json:"claims,omitempty"`
 	// Deprecated, do not use. Use sidecar instead. This field is only used if you're implementing logins in a client-side
 	// frontend application, which we do not recommend. This field will be removed in a future release.
 	// +nais:doc:Deprecated=true
-	SinglePageApplication *bool `json:"singlePageApplication,omitempty"`
+	SinglePageApplication *bool `json:"singlePageApplication,omitempty" nais:"deprecated"`
 	// AllowAllUsers grants all users within the tenant access to this application.
 	// +nais:doc:Default="false"
 	// +nais:doc:Link="https://doc.nais.io/auth/entra-id/how-to/secure/#all-users"
@@ -111,8 +111,8 @@ Instead we are using the rewrite-target annotation and that annotation
 does redirects with a 302 iff the parameters are full urls, scheme and all. */
 
 type Redirect struct {
-	From fromRedirect `json:"from"`
-	To   toRedirect   `json:"to"`
+	From fromRedirect `json:"from" nais:"url=https"`
+	To   toRedirect   `json:"to" nais:"url=https"`
 }
 
 type IDPorten struct {
@@ -151,15 +151,17 @@ type GCP struct {
 	// +nais:doc:Availability=GCP
 	BigQueryDatasets []CloudBigQueryDataset `json:"bigQueryDatasets,omitempty"`
 	// Provision cloud storage buckets and connect them to your application.
+	// Buckets cannot be removed from this list unless `cascadingDelete` is set to true.
 	// +nais:doc:Link="https://doc.nais.io/persistence/buckets/"
 	// +nais:doc:Availability=GCP
-	Buckets []CloudStorageBucket `json:"buckets,omitempty"`
+	Buckets []CloudStorageBucket `json:"buckets,omitempty" nais:"appendonly=cascadingDelete"`
 	// Provision database instances and connect them to your application.
 	// Only one item allowed in the list.
+	// Instances cannot be removed from this list unless `cascadingDelete` is set to true.
 	// +nais:doc:Link="https://doc.nais.io/persistence/postgres/";"https://cloud.google.com/sql/docs/postgres/instance-settings#impact"
 	// +nais:doc:Availability=GCP
 	// +kubebuilder:validation:MaxItems=1
-	SqlInstances []CloudSqlInstance `json:"sqlInstances,omitempty"`
+	SqlInstances []CloudSqlInstance `json:"sqlInstances,omitempty" nais:"appendonly=cascadingDelete"`
 	// List of _additional_ permissions that should be granted to your application for accessing external GCP resources that have not been provisioned through Nais.
 	// +nais:doc:Availability=GCP
 	Permissions []CloudIAMPermission `json:"permissions,omitempty"`
@@ -173,10 +175,10 @@ type EnvVar struct {
 	Name string `json:"name"`
 	// Environment variable value. Numbers and boolean values must be quoted.
 	// Required unless `valueFrom` is specified.
-	Value string `json:"value,omitempty"`
+	Value string `json:"value,omitempty" nais:"mutuallyExclusive=value"`
 	// Dynamically set environment variables based on fields found in the Pod spec.
 	// +nais:doc:Link="https://kubernetes.io/docs/tasks/inject-data-application/environment-variable-expose-pod-information/"
-	ValueFrom *EnvVarSource `json:"valueFrom,omitempty"`
+	ValueFrom *EnvVarSource `json:"valueFrom,omitempty" nais:"mutuallyExclusive=value"`
 }
 
 type EnvVarSource struct {
@@ -186,10 +188,10 @@ type EnvVarSource struct {
 type EnvFrom struct {
 	// Name of the `ConfigMap` where environment variables are specified.
 	// Required unless `secret` is set.
-	ConfigMap string `json:"configmap,omitempty"`
+	ConfigMap string `json:"configmap,omitempty" nais:"oneOf=source"`
 	// Name of the `Secret` where environment variables are specified.
 	// Required unless `configMap` is set.
-	Secret string `json:"secret,omitempty"`
+	Secret string `json:"secret,omitempty" nais:"oneOf=source"`
 }
 
 type ObjectFieldSelector struct {
@@ -201,17 +203,17 @@ type ObjectFieldSelector struct {
 type FilesFrom struct {
 	// Name of the `ConfigMap` that contains files that should be mounted into the container.
 	// Required unless `secret` or `persistentVolumeClaim` is set.
-	ConfigMap string `json:"configmap,omitempty"`
+	ConfigMap string `json:"configmap,omitempty" nais:"oneOf=source"`
 	// Name of the `Secret` that contains files that should be mounted into the container.
 	// Required unless `configMap` or `persistentVolumeClaim` is set.
 	// If mounting multiple secrets, `mountPath` *MUST* be set to avoid collisions.
-	Secret string `json:"secret,omitempty"`
+	Secret string `json:"secret,omitempty" nais:"oneOf=source"`
 	// Specification of an empty directory
-	EmptyDir *EmptyDir `json:"emptyDir,omitempty"`
+	EmptyDir *EmptyDir `json:"emptyDir,omitempty" nais:"oneOf=source,requires=mountPath"`
 	// Name of the `PersistentVolumeClaim` that should be mounted into the container.
 	// Required unless `configMap` or `secret` is set.
 	// This feature requires coordination with the Nais team.
-	PersistentVolumeClaim string `json:"persistentVolumeClaim,omitempty"`
+	PersistentVolumeClaim string `json:"persistentVolumeClaim,omitempty" nais:"oneOf=source"`
 	// Filesystem path inside the pod where files are mounted.
 	// The directory will be created if it does not exist. If the directory exists,
 	// any files in the directory will be made unaccessible.
@@ -243,9 +245,9 @@ type HttpGetAction struct {
 
 type PreStopHook struct {
 	// Command that should be run inside the main container just before the pod is shut down by Kubernetes.
-	Exec *ExecAction `json:"exec,omitempty"`
+	Exec *ExecAction `json:"exec,omitempty" nais:"mutuallyExclusive=action"`
 	// HTTP GET request that is called just before the pod is shut down by Kubernetes.
-	Http *HttpGetAction `json:"http,omitempty"`
+	Http *HttpGetAction `json:"http,omitempty" nais:"mutuallyExclusive=action"`
 }
 
 // Liveness probe and readiness probe definitions.
@@ -365,7 +367,7 @@ type PrometheusConfig struct {
 }
 
 type KafkaScaling struct {
-	// Topic your application is consuming
+	// Topic your application is consuming, named `<namespace>.<name>` after the Topic resource, e.g. `myteam.mytopic`.
 	Topic string `json:"topic"`
 	// ConsumerGroup your application uses when consuming
 	ConsumerGroup string `json:"consumerGroup"`
@@ -400,7 +402,7 @@ type Replicas struct {
 	// Amount of CPU usage before the autoscaler kicks in.
 	// If anything under ScalingStrategy is set, that takes precedence.
 	// +nais:doc:Deprecated=true
-	CpuThresholdPercentage int `json:"cpuThresholdPercentage,omitempty"`
+	CpuThresholdPercentage int `json:"cpuThresholdPercentage,omitempty" nais:"deprecated"`
 	// Disable autoscaling
 	// +nais:doc:Default="false"
 	DisableAutoScaling bool `json:"disableAutoScaling,omitempty"`
@@ -529,7 +531,7 @@ type CloudSqlDatabase struct {
 	// Database name.
 	// *Be aware that only one database with this name is allowed in a namespace, regardless of which SQLInstance it belongs to*
 	// +kubebuilder:validation:Required
-	Name string `json:"name"`
+	Name string `json:"name" nais:"key"`
 	// Prefix to add to environment variables made available for database connection.
 	// If switching to `envVarPrefix` you need to [reset database credentials](https://docs.nais.io/persistence/cloudsql/how-to/reset-database-credentials/).
 	EnvVarPrefix string `json:"envVarPrefix,omitempty"`
@@ -560,7 +562,7 @@ type CloudSqlInstance struct {
 	// +nais:doc:Link="https://cloud.google.com/sql/docs/postgres/instance-settings"
 	Type CloudSqlInstanceType `json:"type"`
 	// The name of the instance, if omitted the application name will be used.
-	Name string `json:"name,omitempty"`
+	Name string `json:"name,omitempty" nais:"key"`
 	// Server tier, i.e. how much CPU and memory allocated.
 	// Available tiers are `db-f1-micro`, `db-g1-small` and custom `db-custom-CPU-RAM`.
 	// Custom instances must specify memory as a multiple of 256 MB and at least 3.75 GB (e.g. `db-custom-1-3840` for 1 cpu, 3840 MB ram).
@@ -576,8 +578,9 @@ type CloudSqlInstance struct {
 	// How much hard drive space to allocate for the SQL server, in gigabytes.
 	// This parameter is used when first provisioning a server.
 	// Disk size can be changed using this field _only when diskAutoresize is set to false_.
+	// The disk size can only be increased.
 	// +kubebuilder:validation:Minimum=10
-	DiskSize int `json:"diskSize,omitempty"`
+	DiskSize int `json:"diskSize,omitempty" nais:"monotonic=increase"`
 	// When set to true, GCP will automatically increase storage by XXX for the database when
 	// disk usage is above the high water mark. Setting this field to true also disables
 	// manual control over disk size, i.e. the `diskSize` parameter will be ignored.
@@ -612,8 +615,9 @@ type CloudSqlInstance struct {
 	Maintenance *Maintenance `json:"maintenance,omitempty"`
 	// List of one database or less(!) that should be created on this Postgres server.
 	// If not present, a default database with the same name as the application will be created.
+	// Databases cannot be removed from this list.
 	// +kubebuilder:validation:MaxItems=1
-	Databases []CloudSqlDatabase `json:"databases,omitempty"`
+	Databases []CloudSqlDatabase `json:"databases,omitempty" nais:"appendonly"`
 	// Remove the entire Postgres server including all data when the Kubernetes resource is deleted.
 	// *THIS IS A DESTRUCTIVE OPERATION*! Set cascading delete only when you want to remove data forever.
 	CascadingDelete bool `json:"cascadingDelete,omitempty"`
@@ -773,7 +777,7 @@ type Observability struct {
 	// Deprecated. Use AutoInstrumentation instead.
 	// +nais:doc:Deprecated=true
 	// +nais:doc:Hidden=true
-	Tracing *Tracing `json:"tracing,omitempty"`
+	Tracing *Tracing `json:"tracing,omitempty" nais:"deprecated"`
 
 	// Configure logging for your application.
 	// +nais:doc:Link="https://doc.nais.io/observability/logging/"
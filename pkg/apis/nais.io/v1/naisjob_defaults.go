@@ -30,6 +30,16 @@ func (in *Naisjob) ApplyDefaults() error {
 	return nil
 }
 
+// ApplyDefaultsPolicy sets values from a defaults policy where they are missing from a Naisjob spec.
+func (in *Naisjob) ApplyDefaultsPolicy(defaults *WorkloadDefaults) error {
+	return mergo.Merge(in, &Naisjob{
+		Spec: NaisjobSpec{
+			Observability: defaults.Observability,
+			Resources:     defaults.Resources,
+		},
+	})
+}
+
 func getNaisjobDefaults() *Naisjob {
 	return &Naisjob{
 		Spec: NaisjobSpec{
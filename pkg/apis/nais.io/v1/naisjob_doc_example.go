@@ -61,6 +61,13 @@ func ExampleNaisjobForDocumentation() *Naisjob {
 								Namespace:   "q3",
 							},
 						},
+						{
+							AccessPolicyRule: AccessPolicyRule{
+								Application: "*",
+								Namespace:   "*",
+								Team:        "myteam",
+							},
+						},
 						{
 							AccessPolicyRule: AccessPolicyRule{
 								Application: "app4",
@@ -106,6 +113,11 @@ func ExampleNaisjobForDocumentation() *Naisjob {
 							Application: "*",
 							Namespace:   "q3",
 						},
+						{
+							Application: "*",
+							Namespace:   "*",
+							Team:        "myteam",
+						},
 					},
 					External: []AccessPolicyExternalRule{
 						{
@@ -121,6 +133,21 @@ func ExampleNaisjobForDocumentation() *Naisjob {
 								},
 							},
 						},
+						{
+							IPv6: "2001:db8::1",
+						},
+						{
+							CIDR: "10.0.0.0/24",
+						},
+						{
+							Host: "*.dns.example.com",
+							Ports: []AccessPolicyPortRule{
+								{
+									Port:     53,
+									Protocol: "UDP",
+								},
+							},
+						},
 					},
 				},
 			},
@@ -175,7 +175,7 @@ type NaisjobSpec struct {
 
 	// The [Cron](https://en.wikipedia.org/wiki/Cron) schedule for running the Naisjob.
 	// If not specified, the Naisjob will be run as a one-shot Job. The timezone for Naisjobs defaults to UTC.
-	Schedule string `json:"schedule,omitempty"`
+	Schedule string `json:"schedule,omitempty" nais:"cron"`
 
 	// Whether to skip injection of NAV certificate authority bundle or not. Defaults to false.
 	SkipCaBundle bool `json:"skipCaBundle,omitempty"`
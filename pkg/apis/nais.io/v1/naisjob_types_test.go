@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"github.com/nais/liberator/pkg/webhookvalidator"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -45,3 +46,7 @@ func minimalNaisjob() *nais_io_v1.Naisjob {
 		},
 	}
 }
+
+func TestNaisjobSpec_Lint(t *testing.T) {
+	assert.NoError(t, webhookvalidator.Lint(nais_io_v1.NaisjobSpec{}))
+}
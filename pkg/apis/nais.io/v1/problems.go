@@ -74,13 +74,18 @@ func (in *Status) AddWarning(specField string, message string) {
 }
 
 // Use AddDeprecation for features that will be changed or removed at a well-defined in the future.
+// If the end-of-life date has not been decided yet, pass the zero value to leave it out.
 func (in *Status) AddDeprecation(specField string, message string, endOfLife time.Time) {
 	in.ensureHasProblemsSlice()
-	endOfLifeDate := endOfLife.Format(time.DateOnly)
+	var endOfLifeDate *string
+	if !endOfLife.IsZero() {
+		date := endOfLife.Format(time.DateOnly)
+		endOfLifeDate = &date
+	}
 	*in.Problems = append(*in.Problems, Problem{
 		Type:      ProblemKindDeprecation, // or warning?
 		Source:    &specField,
-		EndOfLife: &endOfLifeDate,
+		EndOfLife: endOfLifeDate,
 		Message:   message,
 	})
 }
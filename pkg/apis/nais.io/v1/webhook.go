@@ -2,45 +2,40 @@ package nais_io_v1
 
 import (
 	"context"
-	"fmt"
-	"strconv"
-	"time"
 
 	"github.com/go-logr/logr"
-	aiven_io_v1alpha1 "github.com/nais/liberator/pkg/apis/aiven.io/v1alpha1"
-	aiven_nais_io_v1 "github.com/nais/liberator/pkg/apis/aiven.nais.io/v1"
-	"github.com/nais/liberator/pkg/webhookvalidator"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/validation"
-	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
-const (
-	LabelKillAfter = "euthanaisa.nais.io/kill-after"
-)
-
 // +kubebuilder:object:generate=false
 type JobValidator struct {
 	client.Client
 	logger logr.Logger
+	config ValidatorConfig
 }
 
 // +kubebuilder:object:generate=false
-type JobMutator struct{}
+type JobMutator = WorkloadMutator[*Naisjob]
+
+func SetupWebhookWithManager(mgr ctrl.Manager, opts ...ValidatorOption) error {
+	config := NewValidatorConfig(opts...)
+	if err := config.IndexFields(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
 
-func SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr, &Naisjob{}).
 		WithValidator(&JobValidator{
 			Client: mgr.GetClient(),
 			logger: mgr.GetLogger().WithName("naisjob-validator"),
+			config: config,
+		}).
+		WithDefaulter(&JobMutator{
+			Client:   mgr.GetAPIReader(),
+			Config:   config,
+			Defaults: (*Naisjob).ApplyDefaultsPolicy,
 		}).
-		WithDefaulter(&JobMutator{}).
 		Complete()
 }
 
@@ -49,131 +44,26 @@ func SetupWebhookWithManager(mgr ctrl.Manager) error {
 // DISABLE: +kubebuilder:webhook:verbs=create;update,path=/validate-nais-io-v1-naisjob,mutating=false,failurePolicy=fail,groups=nais.io,resources=naisjobs,versions=v1,name=validation.naisjobs.nais.io
 
 func (v *JobValidator) ValidateCreate(ctx context.Context, nj *Naisjob) (warnings admission.Warnings, err error) {
-	if len(nj.GetName()) > validation.LabelValueMaxLength {
-		return nil, apierrors.NewBadRequest(fmt.Sprintf("Naisjob name length must be no more than %d characters", validation.LabelValueMaxLength))
-	}
-
-	if nj.Spec.TTL != "" {
-		if _, err := time.ParseDuration(nj.Spec.TTL); err != nil {
-			return nil, apierrors.NewBadRequest(fmt.Sprintf("TTL is not a valid duration: %q. Example of valid duration is '12h'", nj.Spec.TTL))
-		}
-	}
-
-	if err := v.checkAivenReferences(ctx, nj); err != nil {
-		return nil, err
-	}
-
-	if err := v.checkPostgresReference(ctx, nj); err != nil {
-		return nil, err
-	}
-
-	return nil, nil
+	return v.workloadValidator().ValidateCreate(ctx, nj)
 }
 
 func (v *JobValidator) ValidateUpdate(ctx context.Context, oldA *Naisjob, nj *Naisjob) (warnings admission.Warnings, err error) {
-	if !nj.GetDeletionTimestamp().IsZero() {
-		return nil, nil
-	}
-
-	if err := webhookvalidator.NaisCompare(nj.Spec, oldA.Spec, field.NewPath("spec")); err != nil {
-		if allErrs, ok := err.(errors.Aggregate); ok {
-			return nil, apierrors.NewInvalid(
-				schema.GroupKind{Group: GroupVersion.Group, Kind: "Naisjob"},
-				nj.Name,
-				fromAggregate(allErrs),
-			)
-		}
-
-		return nil, err
-	}
-
-	if err := v.checkAivenReferences(ctx, nj); err != nil {
-		return nil, err
-	}
-
-	if err := v.checkPostgresReference(ctx, nj); err != nil {
-		return nil, err
-	}
-
-	return nil, nil
+	return v.workloadValidator().ValidateUpdate(ctx, oldA, nj)
 }
 
 func (v *JobValidator) ValidateDelete(ctx context.Context, obj *Naisjob) (warnings admission.Warnings, err error) {
-	return nil, nil
-}
-
-func (v *JobValidator) checkAivenReferences(ctx context.Context, nj *Naisjob) error {
-	if nj.Spec.OpenSearch != nil && nj.Spec.OpenSearch.Instance != "" {
-		fullyQualifiedName := aiven_nais_io_v1.OpenSearchFullyQualifiedName(nj.Spec.OpenSearch.Instance, nj.Namespace)
-		opensearch := &aiven_io_v1alpha1.OpenSearch{}
-		if err := v.Get(ctx, client.ObjectKey{Name: fullyQualifiedName, Namespace: nj.Namespace}, opensearch); err != nil {
-			if apierrors.IsNotFound(err) {
-				return apierrors.NewBadRequest(fmt.Sprintf("OpenSearch '%s' does not exist. Create the OpenSearch instance first.", nj.Spec.OpenSearch.Instance))
-			}
-			return apierrors.NewInternalError(fmt.Errorf("could not validate OpenSearch reference: %w", err))
-		}
-	}
-
-	for _, valkey := range nj.Spec.Valkey {
-		fullyQualifiedName := aiven_nais_io_v1.ValkeyFullyQualifiedName(valkey.Instance, nj.Namespace)
-		valkeyObj := &aiven_io_v1alpha1.Valkey{}
-		if err := v.Get(ctx, client.ObjectKey{Name: fullyQualifiedName, Namespace: nj.Namespace}, valkeyObj); err != nil {
-			if apierrors.IsNotFound(err) {
-				return apierrors.NewBadRequest(fmt.Sprintf("Valkey '%s' does not exist. Create the Valkey instance first.", valkey.Instance))
-			}
-			return apierrors.NewInternalError(fmt.Errorf("could not validate Valkey reference: %w", err))
-		}
-	}
-	return nil
-}
-
-func (v *JobValidator) checkPostgresReference(ctx context.Context, nj *Naisjob) error {
-	if nj.Spec.Postgres != nil && nj.Spec.Postgres.ClusterName != "" {
-		pgMetaData := &metav1.PartialObjectMetadata{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "data.nais.io/v1",
-				Kind:       "Postgres",
-			},
-		}
-		if err := v.Get(ctx, client.ObjectKey{Name: nj.Spec.Postgres.ClusterName, Namespace: nj.GetNamespace()}, pgMetaData); err != nil {
-			if apierrors.IsNotFound(err) {
-				v.logger.Info("Rejecting naisjob because the Postgres cluster does not exist",
-					"naisjob", nj.GetName(),
-					"namespace", nj.GetNamespace(),
-					"postgresCluster", nj.Spec.Postgres.ClusterName,
-				)
-				return apierrors.NewBadRequest(fmt.Sprintf("Postgres '%s' does not exist. Create the Postgres cluster first.", nj.Spec.Postgres.ClusterName))
-			}
-			v.logger.Error(err, "internal error when validating Postgres reference")
-			return apierrors.NewInternalError(fmt.Errorf("could not validate Postgres reference: %w", err))
-		}
-	}
-	return nil
-}
-
-func fromAggregate(agg errors.Aggregate) field.ErrorList {
-	errs := agg.Errors()
-	list := make(field.ErrorList, len(errs))
-	for i := range errs {
-		list[i] = errs[i].(*field.Error)
-	}
-	return list
+	return v.workloadValidator().ValidateDelete(ctx, obj)
 }
 
-// Default implements webhook.CustomDefaulter - sets kill-after label if TTL is specified
-func (m *JobMutator) Default(ctx context.Context, nj *Naisjob) error {
-	if nj.Spec.TTL == "" {
-		return nil
-	}
-
-	d, err := time.ParseDuration(nj.Spec.TTL)
-	if err != nil {
-		return nil // Validation webhook will catch this
-	}
-
-	if nj.Labels == nil {
-		nj.Labels = make(map[string]string)
+func (v *JobValidator) workloadValidator() *WorkloadValidator[*Naisjob] {
+	return &WorkloadValidator[*Naisjob]{
+		Client: v.Client,
+		Logger: v.logger,
+		Config: v.config,
+		Kind:   "Naisjob",
+		Spec: func(nj *Naisjob) any {
+			return nj.Spec
+		},
+		Checks: naisjobChecks,
 	}
-	nj.Labels[LabelKillAfter] = strconv.FormatInt(time.Now().Add(d).Unix(), 10)
-	return nil
 }
@@ -0,0 +1,136 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// WithInboundWildcards allows inbound access policy rules matching all namespaces.
+// Without it, such rules may only be used for outbound traffic.
+func WithInboundWildcards() ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.AllowInboundWildcards = true
+	}
+}
+
+// checkAccessPolicy validates the use of wildcards and external rules.
+// One-sided rules are reported by the check added with accesspolicy.WithWarnings.
+func checkAccessPolicy(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	return nil, req.Config.ValidateAccessPolicy(req.Object.GetAccessPolicy(), field.NewPath("spec", "accessPolicy")).ToAggregate()
+}
+
+// ValidateAccessPolicy checks that wildcards are only used for whole application and namespace names, and not for clusters,
+// and that external rules have valid addresses and ports.
+//
+// Inbound rules may only match all namespaces if allowed by WithInboundWildcards. Inbound rules that match all applications
+// in all namespaces must select a team, and permissions cannot be granted to rules matching all namespaces.
+func (cfg ValidatorConfig) ValidateAccessPolicy(policy *AccessPolicy, path *field.Path) field.ErrorList {
+	if policy == nil {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	if policy.Outbound != nil {
+		for i, rule := range policy.Outbound.Rules {
+			allErrs = append(allErrs, validateAccessPolicyRule(rule, path.Child("outbound", "rules").Index(i))...)
+		}
+		for i, rule := range policy.Outbound.External {
+			allErrs = append(allErrs, validateExternalRule(rule, path.Child("outbound", "external").Index(i))...)
+		}
+	}
+	if policy.Inbound == nil {
+		return allErrs
+	}
+
+	for i, rule := range policy.Inbound.Rules {
+		rulePath := path.Child("inbound", "rules").Index(i)
+		allErrs = append(allErrs, validateAccessPolicyRule(rule.AccessPolicyRule, rulePath)...)
+		if rule.Namespace != AccessPolicyWildcard {
+			continue
+		}
+
+		switch {
+		case !cfg.AllowInboundWildcards:
+			allErrs = append(allErrs, field.Forbidden(rulePath.Child("namespace"), "wildcards are not allowed for namespaces in inbound rules"))
+		case rule.Application == AccessPolicyWildcard && len(rule.Team) == 0:
+			allErrs = append(allErrs, field.Required(rulePath.Child("team"), "must select a team when matching all applications in all namespaces"))
+		}
+		if rule.Permissions != nil {
+			allErrs = append(allErrs, field.Forbidden(rulePath.Child("permissions"), "permissions cannot be granted to rules matching all namespaces"))
+		}
+	}
+	return allErrs
+}
+
+// ValidateTokenClientRules rejects inbound rules with wildcards or team selectors on workloads with an Azure AD application
+// or a TokenX client, given by the paths of the fields that enable them. The inbound rules are passed on to the
+// AzureAdApplication and Jwker of the workload, which only accept rules that name a single application.
+func ValidateTokenClientRules(policy *AccessPolicy, clients []string, path *field.Path) field.ErrorList {
+	if policy == nil || policy.Inbound == nil || len(clients) == 0 {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	for i, rule := range policy.Inbound.Rules {
+		if rule.IsSelector() {
+			allErrs = append(allErrs, field.Forbidden(path.Child("inbound", "rules").Index(i), fmt.Sprintf("wildcards and team selectors cannot be used with %s, as inbound rules also grant access to tokens", strings.Join(clients, " or "))))
+		}
+	}
+	return allErrs
+}
+
+func validateAccessPolicyRule(rule AccessPolicyRule, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, f := range []struct {
+		name  string
+		value string
+	}{
+		{"application", rule.Application},
+		{"namespace", rule.Namespace},
+	} {
+		if f.value != AccessPolicyWildcard && strings.Contains(f.value, AccessPolicyWildcard) {
+			allErrs = append(allErrs, field.Invalid(path.Child(f.name), f.value, "must be either a name or '*'"))
+		}
+	}
+	if strings.Contains(rule.Cluster, AccessPolicyWildcard) {
+		allErrs = append(allErrs, field.Invalid(path.Child("cluster"), rule.Cluster, "wildcards are not supported"))
+	}
+	return allErrs
+}
+
+// validateExternalRule checks the parts of an external rule that cannot be expressed as patterns in the CRD.
+// Wildcard hosts are also rejected by the pattern in the CRD, but not by it alone when validating outside of the API server.
+func validateExternalRule(rule AccessPolicyExternalRule, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if strings.Contains(rule.Host, AccessPolicyWildcard) {
+		allErrs = append(allErrs, field.Invalid(path.Child("host"), rule.Host, "wildcards are not supported, as FQDNNetworkPolicies only match whole host names; add a rule for each host"))
+	}
+
+	for _, f := range []struct {
+		name  string
+		value string
+	}{
+		{"ipv4", rule.IPv4},
+		{"ipv6", rule.IPv6},
+		{"cidr", rule.CIDR},
+	} {
+		if len(f.value) == 0 {
+			continue
+		}
+		if _, _, err := rule.Prefix(); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child(f.name), f.value, err.Error()))
+		}
+		break
+	}
+
+	for i, port := range rule.Ports {
+		if port.Port < 1 || port.Port > 65535 {
+			allErrs = append(allErrs, field.Invalid(path.Child("ports").Index(i).Child("port"), port.Port, "must be between 1 and 65535"))
+		}
+	}
+	return allErrs
+}
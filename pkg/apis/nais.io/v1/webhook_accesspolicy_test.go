@@ -0,0 +1,171 @@
+package nais_io_v1
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestAccessPolicyRule_Matches(t *testing.T) {
+	for name, tt := range map[string]struct {
+		rule    AccessPolicyRule
+		matches bool
+	}{
+		"exact":                        {rule: AccessPolicyRule{Application: "app", Namespace: "ns"}, matches: true},
+		"other application":            {rule: AccessPolicyRule{Application: "other", Namespace: "ns"}},
+		"other namespace":              {rule: AccessPolicyRule{Application: "app", Namespace: "other"}},
+		"same cluster":                 {rule: AccessPolicyRule{Application: "app", Namespace: "ns", Cluster: "dev"}, matches: true},
+		"other cluster":                {rule: AccessPolicyRule{Application: "app", Namespace: "ns", Cluster: "prod"}},
+		"all applications":             {rule: AccessPolicyRule{Application: "*", Namespace: "ns"}, matches: true},
+		"all namespaces":               {rule: AccessPolicyRule{Application: "app", Namespace: "*"}, matches: true},
+		"all applications in ns":       {rule: AccessPolicyRule{Application: "*", Namespace: "other"}},
+		"partial wildcard":             {rule: AccessPolicyRule{Application: "a*", Namespace: "ns"}},
+		"namespace from WithNamespace": {rule: AccessPolicyRule{Application: "app"}.WithNamespace("ns"), matches: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.matches, tt.rule.Matches("dev", "ns", "app"))
+		})
+	}
+
+	assert.True(t, AccessPolicyRule{}.MatchesTeam("team"))
+	assert.True(t, AccessPolicyRule{Team: "team"}.MatchesTeam("team"))
+	assert.False(t, AccessPolicyRule{Team: "other"}.MatchesTeam("team"))
+	assert.Equal(t, "other", AccessPolicyRule{Namespace: "other"}.WithNamespace("ns").Namespace)
+}
+
+func TestValidateAccessPolicy(t *testing.T) {
+	inbound := func(rules ...AccessPolicyInboundRule) *AccessPolicy {
+		return &AccessPolicy{Inbound: &AccessPolicyInbound{Rules: rules}}
+	}
+	rule := func(application, namespace, team string) AccessPolicyInboundRule {
+		return AccessPolicyInboundRule{AccessPolicyRule: AccessPolicyRule{Application: application, Namespace: namespace, Team: team}}
+	}
+	path := field.NewPath("spec", "accessPolicy")
+	wildcards := NewValidatorConfig(WithInboundWildcards())
+
+	t.Run("no wildcards", func(t *testing.T) {
+		errs := ValidatorConfig{}.ValidateAccessPolicy(inbound(rule("app", "", ""), rule("app", "ns", "team")), path)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	t.Run("outbound wildcards", func(t *testing.T) {
+		errs := ValidatorConfig{}.ValidateAccessPolicy(&AccessPolicy{Outbound: &AccessPolicyOutbound{Rules: []AccessPolicyRule{
+			{Application: "*", Namespace: "ns"},
+			{Application: "app", Namespace: "*"},
+			{Application: "*", Namespace: "*"},
+		}}}, path)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	t.Run("partial wildcards", func(t *testing.T) {
+		errs := ValidatorConfig{}.ValidateAccessPolicy(&AccessPolicy{Outbound: &AccessPolicyOutbound{Rules: []AccessPolicyRule{
+			{Application: "app-*", Namespace: "team*", Cluster: "*"},
+		}}}, path)
+		assert.Len(t, errs, 3)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.rules[0].application: Invalid value: "app-*": must be either a name or '*'`)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.rules[0].namespace: Invalid value: "team*": must be either a name or '*'`)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.rules[0].cluster: Invalid value: "*": wildcards are not supported`)
+	})
+
+	t.Run("external rules", func(t *testing.T) {
+		errs := ValidatorConfig{}.ValidateAccessPolicy(&AccessPolicy{Outbound: &AccessPolicyOutbound{External: []AccessPolicyExternalRule{
+			{Host: "example.com"},
+			{Host: "dns.example.com", Ports: []AccessPolicyPortRule{{Port: 53, Protocol: "UDP"}}},
+			{IPv4: "10.0.0.1"},
+			{IPv6: "2001:db8::1"},
+			{CIDR: "10.0.0.0/8"},
+			{CIDR: "2001:db8::/32"},
+		}}}, path)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	t.Run("invalid external rules", func(t *testing.T) {
+		errs := ValidatorConfig{}.ValidateAccessPolicy(&AccessPolicy{Outbound: &AccessPolicyOutbound{External: []AccessPolicyExternalRule{
+			{Host: "*.com"},
+			{IPv4: "10.0.0"},
+			{IPv6: "10.0.0.1"},
+			{IPv6: "::ffff:10.0.0.1"},
+			{CIDR: "10.0.0.1"},
+			{CIDR: "10.0.0.1/8"},
+			{Host: "example.com", Ports: []AccessPolicyPortRule{{Port: 0}, {Port: 70000}}},
+		}}}, path)
+		assert.Len(t, errs, 8)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.external[0].host: Invalid value: "*.com": wildcards are not supported, as FQDNNetworkPolicies only match whole host names; add a rule for each host`)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.external[1].ipv4: Invalid value: "10.0.0": not an IPv4 address`)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.external[2].ipv6: Invalid value: "10.0.0.1": not an IPv6 address`)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.external[3].ipv6: Invalid value: "::ffff:10.0.0.1": not an IPv6 address`)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.external[4].cidr: Invalid value: "10.0.0.1": not a network in CIDR notation, e.g. 10.0.0.0/8`)
+		assert.Contains(t, err.Error(), `spec.accessPolicy.outbound.external[5].cidr: Invalid value: "10.0.0.1/8": not a network address; did you mean 10.0.0.0/8?`)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.outbound.external[6].ports[0].port: Invalid value: 0: must be between 1 and 65535")
+		assert.Contains(t, err.Error(), "spec.accessPolicy.outbound.external[6].ports[1].port: Invalid value: 70000: must be between 1 and 65535")
+	})
+
+	t.Run("inbound wildcard application", func(t *testing.T) {
+		errs := ValidatorConfig{}.ValidateAccessPolicy(inbound(rule("*", "ns", ""), rule("*", "", "")), path)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	t.Run("inbound wildcard namespace not allowed", func(t *testing.T) {
+		errs := ValidatorConfig{}.ValidateAccessPolicy(inbound(rule("app", "*", ""), rule("*", "*", "team")), path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.inbound.rules[0].namespace: Forbidden: wildcards are not allowed for namespaces in inbound rules")
+		assert.Contains(t, err.Error(), "spec.accessPolicy.inbound.rules[1].namespace: Forbidden: wildcards are not allowed for namespaces in inbound rules")
+	})
+
+	t.Run("inbound wildcards allowed", func(t *testing.T) {
+		errs := wildcards.ValidateAccessPolicy(inbound(rule("*", "ns", ""), rule("app", "*", ""), rule("*", "*", "team")), path)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	t.Run("inbound wildcards without team", func(t *testing.T) {
+		errs := wildcards.ValidateAccessPolicy(inbound(rule("*", "*", "")), path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.inbound.rules[0].team: Required value: must select a team when matching all applications in all namespaces")
+	})
+
+	t.Run("inbound wildcards with permissions", func(t *testing.T) {
+		errs := wildcards.ValidateAccessPolicy(inbound(AccessPolicyInboundRule{
+			AccessPolicyRule: AccessPolicyRule{Application: "app", Namespace: "*"},
+			Permissions:      &AccessPolicyPermissions{Scopes: []AccessPolicyPermission{"read"}},
+		}), path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.inbound.rules[0].permissions: Forbidden: permissions cannot be granted to rules matching all namespaces")
+	})
+}
+
+func TestValidateTokenClientRules(t *testing.T) {
+	path := field.NewPath("spec", "accessPolicy")
+	policy := &AccessPolicy{Inbound: &AccessPolicyInbound{Rules: []AccessPolicyInboundRule{
+		{AccessPolicyRule: AccessPolicyRule{Application: "app", Namespace: "ns"}},
+		{AccessPolicyRule: AccessPolicyRule{Application: "*", Namespace: "ns"}},
+		{AccessPolicyRule: AccessPolicyRule{Application: "app", Namespace: "*"}},
+		{AccessPolicyRule: AccessPolicyRule{Application: "*", Namespace: "*", Team: "team"}},
+	}}}
+
+	t.Run("with token clients", func(t *testing.T) {
+		errs := ValidateTokenClientRules(policy, []string{"spec.azure.application", "spec.tokenx"}, path)
+		assert.Len(t, errs, 3)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		for _, i := range []int{1, 2, 3} {
+			assert.Contains(t, err.Error(), fmt.Sprintf("spec.accessPolicy.inbound.rules[%d]: Forbidden: wildcards and team selectors cannot be used with spec.azure.application or spec.tokenx, as inbound rules also grant access to tokens", i))
+		}
+		assert.NotContains(t, err.Error(), "rules[0]")
+	})
+
+	t.Run("without token clients", func(t *testing.T) {
+		assert.NoError(t, ValidateTokenClientRules(policy, nil, path).ToAggregate())
+	})
+}
@@ -0,0 +1,90 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	aiven_io_v1alpha1 "github.com/nais/liberator/pkg/apis/aiven.io/v1alpha1"
+	aiven_nais_io_v1 "github.com/nais/liberator/pkg/apis/aiven.nais.io/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// AivenTeamTag is the tag on Aiven services that holds the name of the owning team.
+const AivenTeamTag = "team"
+
+// AivenAccessLevels are the valid values for `access` on OpenSearch and Valkey references.
+var AivenAccessLevels = []string{"read", "write", "readwrite", "admin"}
+
+// ValidateAivenReferences checks that the OpenSearch and Valkey instances referenced by a workload exist
+// in its namespace, are owned by the same team, and are requested with a valid access level.
+//
+// Missing instances, invalid access levels and instances owned by other teams are returned as field errors.
+// The error is non-nil if an instance could not be looked up.
+func ValidateAivenReferences(ctx context.Context, reader client.Reader, obj AivenInterface, namespace string) (field.ErrorList, error) {
+	var allErrs field.ErrorList
+	spec := field.NewPath("spec")
+
+	if opensearch := obj.GetOpenSearch(); opensearch != nil && opensearch.Instance != "" {
+		path := spec.Child("openSearch")
+		allErrs = append(allErrs, validateAivenAccess(opensearch.Access, path.Child("access"))...)
+
+		instance := &aiven_io_v1alpha1.OpenSearch{}
+		key := client.ObjectKey{Name: aiven_nais_io_v1.OpenSearchFullyQualifiedName(opensearch.Instance, namespace), Namespace: namespace}
+		if err := reader.Get(ctx, key, instance); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, apierrors.NewInternalError(fmt.Errorf("could not validate OpenSearch reference: %w", err))
+			}
+			allErrs = append(allErrs, field.Invalid(path.Child("instance"), opensearch.Instance, fmt.Sprintf("OpenSearch '%s' does not exist. Create the OpenSearch instance first.", opensearch.Instance)))
+		} else {
+			allErrs = append(allErrs, validateAivenTeam(instance.Spec.Tags, namespace, opensearch.Instance, path.Child("instance"))...)
+		}
+	}
+
+	for i, valkey := range obj.GetValkey() {
+		path := spec.Child("valkey").Index(i)
+		allErrs = append(allErrs, validateAivenAccess(valkey.Access, path.Child("access"))...)
+
+		instance := &aiven_io_v1alpha1.Valkey{}
+		key := client.ObjectKey{Name: aiven_nais_io_v1.ValkeyFullyQualifiedName(valkey.Instance, namespace), Namespace: namespace}
+		if err := reader.Get(ctx, key, instance); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return nil, apierrors.NewInternalError(fmt.Errorf("could not validate Valkey reference: %w", err))
+			}
+			allErrs = append(allErrs, field.Invalid(path.Child("instance"), valkey.Instance, fmt.Sprintf("Valkey '%s' does not exist. Create the Valkey instance first.", valkey.Instance)))
+			continue
+		}
+		allErrs = append(allErrs, validateAivenTeam(instance.Spec.Tags, namespace, valkey.Instance, path.Child("instance"))...)
+	}
+
+	return allErrs, nil
+}
+
+func checkAivenReferences(ctx context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	allErrs, err := ValidateAivenReferences(ctx, req.Client, req.Object, req.Object.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+	return nil, allErrs.ToAggregate()
+}
+
+// validateAivenAccess checks the access level. An empty access level is allowed, and defaults to `read`.
+func validateAivenAccess(access string, path *field.Path) field.ErrorList {
+	if access == "" || slices.Contains(AivenAccessLevels, access) {
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(path, access, AivenAccessLevels)}
+}
+
+// validateAivenTeam checks that an instance is owned by the given team.
+// Instances without a team tag are not checked.
+func validateAivenTeam(tags map[string]string, team, instance string, path *field.Path) field.ErrorList {
+	owner, ok := tags[AivenTeamTag]
+	if !ok || owner == team {
+		return nil
+	}
+	return field.ErrorList{field.Forbidden(path, fmt.Sprintf("instance %q is owned by team %q, not %q", instance, owner, team))}
+}
@@ -0,0 +1,206 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ReservedIngressPaths are path prefixes that are served by sidecars, and cannot be used as ingress paths.
+var ReservedIngressPaths = []string{"/oauth2"}
+
+// WithIngressDomains rejects ingresses on domains that are not in the given list.
+// A domain starting with `*.` matches exactly one additional label, e.g. `*.example.com` matches `foo.example.com`,
+// but neither `example.com` nor `foo.bar.example.com`.
+func WithIngressDomains(domains ...string) ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.IngressDomains = domains
+	}
+}
+
+// ParsedIngress is an ingress URL split into its normalized host and path.
+// +kubebuilder:object:generate=false
+type ParsedIngress struct {
+	Host string
+	// Path always starts with a slash, and never ends with one unless it is the root path.
+	Path string
+}
+
+func (in ParsedIngress) String() string {
+	return in.Host + in.Path
+}
+
+// ParseIngress parses an ingress URL, which must be an absolute https URL without query or fragment.
+func ParseIngress(ingress string) (ParsedIngress, error) {
+	u, err := url.Parse(ingress)
+	if err != nil {
+		return ParsedIngress{}, err
+	}
+	if u.Scheme != "https" {
+		return ParsedIngress{}, fmt.Errorf("scheme must be https")
+	}
+	if len(u.Hostname()) == 0 {
+		return ParsedIngress{}, fmt.Errorf("missing host")
+	}
+	if len(u.Port()) > 0 {
+		return ParsedIngress{}, fmt.Errorf("port cannot be specified")
+	}
+	if len(u.RawQuery) > 0 || len(u.Fragment) > 0 {
+		return ParsedIngress{}, fmt.Errorf("query and fragment are not allowed")
+	}
+
+	p := strings.TrimSuffix(u.Path, "/")
+	if len(p) == 0 {
+		p = "/"
+	}
+	return ParsedIngress{Host: strings.ToLower(u.Hostname()), Path: p}, nil
+}
+
+// HasPathPrefix returns true if the path of the ingress is equal to, or below, the given path.
+func (in ParsedIngress) HasPathPrefix(prefix string) bool {
+	return prefix == "/" || in.Path == prefix || strings.HasPrefix(in.Path, prefix+"/")
+}
+
+func checkIngresses(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	return nil, req.Config.validateIngresses(req.Object.GetIngress(), field.NewPath("spec", "ingresses")).ToAggregate()
+}
+
+func (cfg ValidatorConfig) validateIngresses(ingresses []Ingress, path *field.Path) (allErrs field.ErrorList) {
+	seen := map[string]bool{}
+	for i, ingress := range ingresses {
+		parsed, err := ParseIngress(string(ingress))
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), ingress, err.Error()))
+			continue
+		}
+
+		if seen[parsed.String()] {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i), ingress))
+		}
+		seen[parsed.String()] = true
+
+		if len(cfg.IngressDomains) > 0 && !domainAllowed(parsed.Host, cfg.IngressDomains) {
+			allErrs = append(allErrs, field.Invalid(path.Index(i), ingress, fmt.Sprintf("domain is not available in this cluster; allowed domains are: %s", strings.Join(cfg.IngressDomains, ", "))))
+		}
+
+		for _, reserved := range ReservedIngressPaths {
+			if parsed.HasPathPrefix(reserved) {
+				allErrs = append(allErrs, field.Invalid(path.Index(i), ingress, fmt.Sprintf("paths starting with %s are reserved", reserved)))
+			}
+		}
+	}
+	return allErrs
+}
+
+func domainAllowed(host string, domains []string) bool {
+	for _, domain := range domains {
+		domain = strings.ToLower(domain)
+		if suffix, ok := strings.CutPrefix(domain, "*."); ok {
+			label, found := strings.CutSuffix(host, "."+suffix)
+			if found && len(label) > 0 && !strings.Contains(label, ".") {
+				return true
+			}
+			continue
+		}
+		if host == domain {
+			return true
+		}
+	}
+	return false
+}
+
+func checkRedirects(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	warnings, allErrs := ValidateRedirects(req.Object.GetRedirects(), req.Object.GetIngress(), field.NewPath("spec", "redirects"))
+	return warnings, allErrs.ToAggregate()
+}
+
+// ValidateRedirects checks that every redirect is served from one of the given ingresses, and that the redirects do not form a loop.
+// A warning is returned for redirects to another of the given ingresses, as the target is served by the same workload.
+//
+// The URL format of `from` and `to` is validated by the field tags on Redirect, and is not reported here.
+// Redirects from URLs that are not valid https URLs are skipped.
+func ValidateRedirects(redirects []Redirect, ingresses []Ingress, path *field.Path) (admission.Warnings, field.ErrorList) {
+	served := map[string]bool{}
+	for _, ingress := range ingresses {
+		if parsed, err := ParseIngress(string(ingress)); err == nil {
+			served[parsed.String()] = true
+		}
+	}
+
+	// targets maps the normalized `from` of each redirect to its normalized `to`.
+	// Targets that are not valid ingresses can never redirect further, and are left out.
+	targets := map[string]string{}
+	for _, redirect := range redirects {
+		from, err := ParseIngress(string(redirect.From))
+		if err != nil {
+			continue
+		}
+		if to, err := ParseIngress(string(redirect.To)); err == nil {
+			targets[from.String()] = to.String()
+		}
+	}
+
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+	seen := map[string]bool{}
+	for i, redirect := range redirects {
+		if !isHTTPSURL(string(redirect.From)) {
+			continue
+		}
+		from, err := ParseIngress(string(redirect.From))
+		if err != nil || !served[from.String()] {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("from"), redirect.From, "must be one of the ingresses of the application"))
+			continue
+		}
+		if seen[from.String()] {
+			allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("from"), redirect.From))
+			continue
+		}
+		seen[from.String()] = true
+
+		to, ok := targets[from.String()]
+		if !ok {
+			continue
+		}
+		if chain := redirectLoop(from.String(), targets); chain != nil {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("to"), redirect.To, fmt.Sprintf("redirect loop: %s", strings.Join(chain, " -> "))))
+			continue
+		}
+		if served[to] {
+			warnings = append(warnings, fmt.Sprintf("%s: %s is also an ingress of this application", path.Index(i).Child("to"), redirect.To))
+		}
+	}
+	return warnings, allErrs
+}
+
+// isHTTPSURL mirrors the `url=https` field tag on Redirect, so that values it rejects are not reported again.
+func isHTTPSURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme == "https" && len(u.Host) > 0
+}
+
+// redirectLoop follows the redirects starting at the given URL, and returns the chain of URLs if it leads back to the start.
+func redirectLoop(start string, targets map[string]string) []string {
+	chain := []string{start}
+	visited := map[string]bool{start: true}
+	for current := start; ; {
+		next, ok := targets[current]
+		if !ok {
+			return nil
+		}
+		chain = append(chain, next)
+		if next == start {
+			return chain
+		}
+		if visited[next] {
+			// A loop that does not include the start is reported for the redirects that are part of it
+			return nil
+		}
+		visited[next] = true
+		current = next
+	}
+}
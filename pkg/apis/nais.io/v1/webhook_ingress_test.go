@@ -0,0 +1,126 @@
+package nais_io_v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateIngresses(t *testing.T) {
+	cfg := NewValidatorConfig(WithIngressDomains("*.intern.nav.no", "nav.no"))
+	path := field.NewPath("spec", "ingresses")
+
+	t.Run("allowed domains", func(t *testing.T) {
+		errs := cfg.validateIngresses([]Ingress{"https://myapp.intern.nav.no", "https://nav.no/myapp", "https://MyApp.Intern.Nav.No/other/"}, path)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	t.Run("domain outside allowlist", func(t *testing.T) {
+		errs := cfg.validateIngresses([]Ingress{"https://myapp.example.com", "https://intern.nav.no", "https://a.b.intern.nav.no"}, path)
+		assert.Len(t, errs, 3)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.ingresses[0]: Invalid value: "https://myapp.example.com": domain is not available in this cluster; allowed domains are: *.intern.nav.no, nav.no`)
+		assert.Contains(t, err.Error(), `spec.ingresses[1]: Invalid value: "https://intern.nav.no": domain is not available in this cluster`)
+		assert.Contains(t, err.Error(), `spec.ingresses[2]: Invalid value: "https://a.b.intern.nav.no": domain is not available in this cluster`)
+	})
+
+	t.Run("non-https scheme", func(t *testing.T) {
+		errs := cfg.validateIngresses([]Ingress{"http://myapp.intern.nav.no", "myapp.intern.nav.no"}, path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.ingresses[0]: Invalid value: "http://myapp.intern.nav.no": scheme must be https`)
+		assert.Contains(t, err.Error(), `spec.ingresses[1]: Invalid value: "myapp.intern.nav.no": scheme must be https`)
+	})
+
+	t.Run("duplicate host and path", func(t *testing.T) {
+		errs := cfg.validateIngresses([]Ingress{"https://myapp.intern.nav.no/api", "https://myapp.intern.nav.no/api/", "https://myapp.intern.nav.no"}, path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.ingresses[1]: Duplicate value: "https://myapp.intern.nav.no/api/"`)
+	})
+
+	t.Run("reserved paths", func(t *testing.T) {
+		errs := cfg.validateIngresses([]Ingress{"https://nav.no/oauth2", "https://nav.no/oauth2/callback", "https://nav.no/oauth2-not-reserved"}, path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.ingresses[0]: Invalid value: "https://nav.no/oauth2": paths starting with /oauth2 are reserved`)
+		assert.Contains(t, err.Error(), `spec.ingresses[1]: Invalid value: "https://nav.no/oauth2/callback": paths starting with /oauth2 are reserved`)
+	})
+}
+
+func TestValidateIngresses_NoAllowlist(t *testing.T) {
+	errs := ValidatorConfig{}.validateIngresses([]Ingress{"https://anything.example.com"}, field.NewPath("spec", "ingresses"))
+	assert.Empty(t, errs)
+}
+
+func TestValidateRedirects(t *testing.T) {
+	ingresses := []Ingress{"https://myapp.intern.nav.no", "https://myapp-old.intern.nav.no", "https://myapp.intern.nav.no/v1"}
+	path := field.NewPath("spec", "redirects")
+
+	t.Run("redirect to another domain", func(t *testing.T) {
+		warnings, errs := ValidateRedirects([]Redirect{{From: "https://myapp-old.intern.nav.no/", To: "https://myapp.nav.no"}}, ingresses, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("redirect from an ingress of another application", func(t *testing.T) {
+		warnings, errs := ValidateRedirects([]Redirect{{From: "https://other.intern.nav.no", To: "https://myapp.nav.no"}}, ingresses, path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.redirects[0].from: Invalid value: "https://other.intern.nav.no": must be one of the ingresses of the application`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("malformed redirects are left to the field tags", func(t *testing.T) {
+		warnings, errs := ValidateRedirects([]Redirect{{From: "myapp-old.intern.nav.no", To: "myapp.nav.no"}}, ingresses, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("duplicate redirect", func(t *testing.T) {
+		warnings, errs := ValidateRedirects([]Redirect{
+			{From: "https://myapp-old.intern.nav.no", To: "https://myapp.nav.no"},
+			{From: "https://MYAPP-OLD.intern.nav.no", To: "https://myapp.nav.no/other"},
+		}, ingresses, path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.redirects[1].from: Duplicate value: "https://MYAPP-OLD.intern.nav.no"`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("redirect to itself", func(t *testing.T) {
+		warnings, errs := ValidateRedirects([]Redirect{{From: "https://myapp.intern.nav.no/v1", To: "https://myapp.intern.nav.no/v1/"}}, ingresses, path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.redirects[0].to: Invalid value: "https://myapp.intern.nav.no/v1/": redirect loop: myapp.intern.nav.no/v1 -> myapp.intern.nav.no/v1`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("redirect loop", func(t *testing.T) {
+		warnings, errs := ValidateRedirects([]Redirect{
+			{From: "https://myapp-old.intern.nav.no", To: "https://myapp.intern.nav.no/v1"},
+			{From: "https://myapp.intern.nav.no/v1", To: "https://myapp-old.intern.nav.no"},
+		}, ingresses, path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.redirects[0].to: Invalid value: "https://myapp.intern.nav.no/v1": redirect loop: myapp-old.intern.nav.no/ -> myapp.intern.nav.no/v1 -> myapp-old.intern.nav.no/`)
+		assert.Contains(t, err.Error(), `spec.redirects[1].to: Invalid value: "https://myapp-old.intern.nav.no": redirect loop: myapp.intern.nav.no/v1 -> myapp-old.intern.nav.no/ -> myapp.intern.nav.no/v1`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("redirect to an ingress of the same application", func(t *testing.T) {
+		warnings, errs := ValidateRedirects([]Redirect{{From: "https://myapp-old.intern.nav.no", To: "https://myapp.intern.nav.no"}}, ingresses, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{"spec.redirects[0].to: https://myapp.intern.nav.no is also an ingress of this application"}, warnings)
+	})
+}
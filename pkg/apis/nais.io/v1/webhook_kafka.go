@@ -0,0 +1,101 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sync"
+
+	kafka_nais_io_v1 "github.com/nais/liberator/pkg/apis/kafka.nais.io/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// TopicPoolIndex is the name of the field index on the pool of a Topic.
+const TopicPoolIndex = "spec.pool"
+
+// topicPoolIndexers keeps track of the indexers that already have TopicPoolIndex,
+// as both the Application and Naisjob webhooks need it, and an index can only be registered once.
+var topicPoolIndexers sync.Map
+
+// TopicPool extracts the pool of a Topic, for use with TopicPoolIndex.
+func TopicPool(obj client.Object) []string {
+	topic, ok := obj.(*kafka_nais_io_v1.Topic)
+	if !ok || len(topic.Spec.Pool) == 0 {
+		return nil
+	}
+	return []string{topic.Spec.Pool}
+}
+
+// IndexTopicPools registers TopicPoolIndex with the given indexer, unless it has already been registered.
+func IndexTopicPools(ctx context.Context, indexer client.FieldIndexer) error {
+	if _, registered := topicPoolIndexers.LoadOrStore(indexer, true); registered {
+		return nil
+	}
+	err := indexer.IndexField(ctx, &kafka_nais_io_v1.Topic{}, TopicPoolIndex, TopicPool)
+	if err != nil {
+		topicPoolIndexers.Delete(indexer)
+	}
+	return err
+}
+
+// checkKafka validates the Kafka pool, and warns if the workload is not granted access to any topics.
+// Failing to look up topics is not fatal, as the warning is only advisory.
+func checkKafka(ctx context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	kafka := req.Object.GetKafka()
+	path := field.NewPath("spec", "kafka")
+	if errs := req.Config.ValidateKafkaPool(kafka, path); len(errs) > 0 {
+		return nil, errs.ToAggregate()
+	}
+
+	warnings, err := req.Config.TopicACLWarnings(ctx, req.Client, kafka, req.Object.GetNamespace(), req.Object.GetName(), path)
+	if err != nil {
+		req.Logger.Error(err, "unable to check Topic ACLs", req.logKey(), req.Object.GetName(), "namespace", req.Object.GetNamespace())
+	}
+	return warnings, nil
+}
+
+// ValidateKafkaPool checks that the Kafka pool is one of the configured pools.
+func (cfg ValidatorConfig) ValidateKafkaPool(kafka *Kafka, path *field.Path) field.ErrorList {
+	if kafka == nil || len(cfg.KafkaPools) == 0 {
+		return nil
+	}
+	if !slices.Contains(cfg.KafkaPools, kafka.Pool) {
+		return field.ErrorList{field.NotSupported(path.Child("pool"), kafka.Pool, cfg.KafkaPools)}
+	}
+	return nil
+}
+
+// TopicACLWarnings returns a warning if the given workload uses Kafka, but is not listed in the ACL of any Topic
+// in the same pool. ACL entries may use `*` wildcards for team and application.
+// Topics are listed using TopicPoolIndex, which must be registered with the cache of the reader.
+// Workloads with Kafka Streams enabled always have access to their own stream topics, and are not checked.
+func (cfg ValidatorConfig) TopicACLWarnings(ctx context.Context, reader client.Reader, kafka *Kafka, team, application string, path *field.Path) (admission.Warnings, error) {
+	if kafka == nil || kafka.Streams || !cfg.WarnMissingTopicACL {
+		return nil, nil
+	}
+
+	topics := &kafka_nais_io_v1.TopicList{}
+	if err := reader.List(ctx, topics, client.MatchingFields{TopicPoolIndex: kafka.Pool}); err != nil {
+		return nil, fmt.Errorf("list topics: %w", err)
+	}
+
+	for _, topic := range topics.Items {
+		for _, acl := range topic.Spec.ACL {
+			if aclMatches(acl.Team, team) && aclMatches(acl.Application, application) {
+				return nil, nil
+			}
+		}
+	}
+
+	return admission.Warnings{
+		fmt.Sprintf("%s: %s is not granted access to any Topic in pool %q; add it to the ACL of the topics it should use", path, application, kafka.Pool),
+	}, nil
+}
+
+func aclMatches(pattern, name string) bool {
+	matched, err := path.Match(pattern, name)
+	return err == nil && matched
+}
@@ -0,0 +1,188 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	// Embed the time zone database, so that time zones can be validated on images without one, e.g. distroless
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// scheduleWarningRuns is the number of upcoming runs listed when a schedule looks suspicious.
+const scheduleWarningRuns = 5
+
+// maxIndexedJobCompletions is the upper limit for completions and parallelism of Indexed Jobs enforced by Kubernetes.
+const maxIndexedJobCompletions = 100000
+
+// Valid values for the enumerated Job and CronJob fields of NaisjobSpec.
+var (
+	CompletionModes     = []string{"NonIndexed", "Indexed"}
+	RestartPolicies     = []string{"OnFailure", "Never"}
+	ConcurrencyPolicies = []string{"Allow", "Forbid", "Replace"}
+)
+
+// naisjobChecks only apply to Naisjobs.
+var naisjobChecks = []WorkloadCheck{
+	checkSchedule,
+	checkJobSpec,
+	checkJobTokenClients,
+}
+
+func checkSchedule(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	nj := req.Object.(*Naisjob)
+	spec := field.NewPath("spec")
+
+	loc, allErrs := ValidateTimeZone(nj.Spec.TimeZone, spec.Child("timeZone"))
+	if len(allErrs) > 0 {
+		return nil, allErrs.ToAggregate()
+	}
+
+	sched, err := cron.ParseStandard(nj.Spec.Schedule)
+	if len(nj.Spec.Schedule) == 0 || err != nil {
+		// Invalid schedules are reported by the field tags on NaisjobSpec
+		return nil, nil
+	}
+
+	return ScheduleWarnings(sched, time.Now().In(loc), spec.Child("schedule")), nil
+}
+
+// ValidateTimeZone checks that the time zone is a valid IANA time zone, and returns its location.
+// If no time zone is specified, UTC is used. The time zone database is embedded, so the result does not depend on the host.
+func ValidateTimeZone(timeZone *string, path *field.Path) (*time.Location, field.ErrorList) {
+	if timeZone == nil {
+		return time.UTC, nil
+	}
+
+	// LoadLocation treats these as UTC and the local time zone of the server, which the CronJob controller does not accept.
+	if len(*timeZone) == 0 || strings.EqualFold(*timeZone, "Local") {
+		return nil, field.ErrorList{field.Invalid(path, *timeZone, "must be a valid IANA time zone, e.g. Europe/Oslo")}
+	}
+	loc, err := time.LoadLocation(*timeZone)
+	if err != nil {
+		return nil, field.ErrorList{field.Invalid(path, *timeZone, "must be a valid IANA time zone, e.g. Europe/Oslo")}
+	}
+	return loc, nil
+}
+
+// ScheduleWarnings returns a warning listing the upcoming runs of a schedule if it does not run within a year from now.
+func ScheduleWarnings(sched cron.Schedule, now time.Time, path *field.Path) admission.Warnings {
+	runs := make([]time.Time, 0, scheduleWarningRuns)
+	for t := now; len(runs) < scheduleWarningRuns; {
+		t = sched.Next(t)
+		if t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+
+	var reason string
+	switch {
+	case len(runs) == 0:
+		return admission.Warnings{fmt.Sprintf("%s: the schedule never runs", path)}
+	case runs[0].After(now.AddDate(1, 0, 0)):
+		reason = "the schedule does not run within the next year"
+	default:
+		return nil
+	}
+
+	formatted := make([]string, len(runs))
+	for i, run := range runs {
+		formatted[i] = run.Format(time.RFC3339)
+	}
+	return admission.Warnings{fmt.Sprintf("%s: %s; the next runs are at %s", path, reason, strings.Join(formatted, ", "))}
+}
+
+func checkJobSpec(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	nj := req.Object.(*Naisjob)
+	warnings, allErrs := ValidateJobSpec(nj.Spec, field.NewPath("spec"))
+	return warnings, allErrs.ToAggregate()
+}
+
+// ValidateJobSpec checks the fields of a NaisjobSpec that are passed on to the Job and CronJob,
+// using the same rules as Kubernetes, along with rules for how they combine with the other fields of the Naisjob.
+// Fields that only apply to scheduled Naisjobs, and readiness probes, give a warning when set on a one-shot Naisjob.
+func ValidateJobSpec(spec NaisjobSpec, path *field.Path) (admission.Warnings, field.ErrorList) {
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+
+	allErrs = append(allErrs, validateNonNegative(spec.ActiveDeadlineSeconds, path.Child("activeDeadlineSeconds"))...)
+	allErrs = append(allErrs, validateNonNegative(spec.BackoffLimit, path.Child("backoffLimit"))...)
+	allErrs = append(allErrs, validateNonNegative(spec.Completions, path.Child("completions"))...)
+	allErrs = append(allErrs, validateNonNegative(spec.Parallelism, path.Child("parallelism"))...)
+	allErrs = append(allErrs, validateNonNegative(spec.FailedJobsHistoryLimit, path.Child("failedJobsHistoryLimit"))...)
+	allErrs = append(allErrs, validateNonNegative(&spec.SuccessfulJobsHistoryLimit, path.Child("successfulJobsHistoryLimit"))...)
+	allErrs = append(allErrs, validateNonNegative(spec.TTLSecondsAfterFinished, path.Child("ttlSecondsAfterFinished"))...)
+
+	if spec.CompletionMode != nil {
+		allErrs = append(allErrs, validateEnum(*spec.CompletionMode, CompletionModes, path.Child("completionMode"))...)
+		if *spec.CompletionMode == "Indexed" {
+			switch {
+			case spec.Completions == nil:
+				allErrs = append(allErrs, field.Required(path.Child("completions"), "when completion mode is Indexed"))
+			case *spec.Completions > maxIndexedJobCompletions:
+				allErrs = append(allErrs, field.Invalid(path.Child("completions"), *spec.Completions, fmt.Sprintf("must be less than or equal to %d when completion mode is Indexed", maxIndexedJobCompletions)))
+			}
+			if spec.Parallelism != nil && *spec.Parallelism > maxIndexedJobCompletions {
+				allErrs = append(allErrs, field.Invalid(path.Child("parallelism"), *spec.Parallelism, fmt.Sprintf("must be less than or equal to %d when completion mode is Indexed", maxIndexedJobCompletions)))
+			}
+		}
+	}
+
+	if len(spec.RestartPolicy) > 0 {
+		allErrs = append(allErrs, validateEnum(spec.RestartPolicy, RestartPolicies, path.Child("restartPolicy"))...)
+	}
+	if len(spec.ConcurrencyPolicy) > 0 {
+		allErrs = append(allErrs, validateEnum(spec.ConcurrencyPolicy, ConcurrencyPolicies, path.Child("concurrencyPolicy"))...)
+	}
+
+	if spec.TTLSecondsAfterFinished != nil && len(spec.TTL) > 0 {
+		ttl, err := time.ParseDuration(spec.TTL)
+		afterFinished := time.Duration(*spec.TTLSecondsAfterFinished) * time.Second
+		if err == nil && afterFinished > ttl {
+			allErrs = append(allErrs, field.Invalid(path.Child("ttlSecondsAfterFinished"), *spec.TTLSecondsAfterFinished, fmt.Sprintf("must not be longer than ttl (%s), as the Naisjob and its Jobs are deleted when the ttl expires", spec.TTL)))
+		}
+	}
+
+	if len(spec.Schedule) == 0 {
+		if spec.Readiness != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: has no effect on one-shot Naisjobs, which do not receive traffic", path.Child("readiness")))
+		}
+		if len(spec.ConcurrencyPolicy) > 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: only applies to Naisjobs with a schedule", path.Child("concurrencyPolicy")))
+		}
+		if spec.TimeZone != nil {
+			warnings = append(warnings, fmt.Sprintf("%s: only applies to Naisjobs with a schedule", path.Child("timeZone")))
+		}
+	}
+
+	return warnings, allErrs
+}
+
+func validateNonNegative[T int32 | int64](value *T, path *field.Path) field.ErrorList {
+	if value == nil || *value >= 0 {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(path, *value, "must be greater than or equal to 0")}
+}
+
+func validateEnum(value string, supported []string, path *field.Path) field.ErrorList {
+	if slices.Contains(supported, value) {
+		return nil
+	}
+	return field.ErrorList{field.NotSupported(path, value, supported)}
+}
+
+func checkJobTokenClients(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	nj := req.Object.(*Naisjob)
+	var clients []string
+	if azure := nj.Spec.Azure; azure != nil && azure.Application != nil && azure.Application.Enabled {
+		clients = append(clients, "spec.azure.application")
+	}
+	return nil, ValidateTokenClientRules(nj.Spec.AccessPolicy, clients, field.NewPath("spec", "accessPolicy")).ToAggregate()
+}
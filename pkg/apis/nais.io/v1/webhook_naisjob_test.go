@@ -0,0 +1,131 @@
+package nais_io_v1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestScheduleWarnings(t *testing.T) {
+	leapDay, err := cron.ParseStandard("0 12 29 2 *")
+	require.NoError(t, err)
+
+	now := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	warnings := ScheduleWarnings(leapDay, now, field.NewPath("spec", "schedule"))
+	assert.Equal(t, []string{
+		"spec.schedule: the schedule does not run within the next year; the next runs are at " +
+			"2028-02-29T12:00:00Z, 2032-02-29T12:00:00Z, 2036-02-29T12:00:00Z, 2040-02-29T12:00:00Z, 2044-02-29T12:00:00Z",
+	}, []string(warnings))
+
+	now = time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Empty(t, ScheduleWarnings(leapDay, now, field.NewPath("spec", "schedule")))
+}
+
+func TestValidateJobSpec(t *testing.T) {
+	path := field.NewPath("spec")
+
+	t.Run("valid scheduled job", func(t *testing.T) {
+		warnings, errs := ValidateJobSpec(NaisjobSpec{
+			Schedule:                "@daily",
+			Completions:             new(int32(3)),
+			CompletionMode:          new("Indexed"),
+			Parallelism:             new(int32(3)),
+			RestartPolicy:           "OnFailure",
+			ConcurrencyPolicy:       "Forbid",
+			Readiness:               &Probe{Path: "/ready"},
+			TTL:                     "1h",
+			TTLSecondsAfterFinished: new(int32(3600)),
+		}, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("negative values", func(t *testing.T) {
+		warnings, errs := ValidateJobSpec(NaisjobSpec{
+			Schedule:                   "@daily",
+			ActiveDeadlineSeconds:      new(int64(-1)),
+			BackoffLimit:               new(int32(-1)),
+			Completions:                new(int32(-1)),
+			Parallelism:                new(int32(-1)),
+			FailedJobsHistoryLimit:     new(int32(-1)),
+			SuccessfulJobsHistoryLimit: -1,
+			TTLSecondsAfterFinished:    new(int32(-1)),
+		}, path)
+		assert.Len(t, errs, 7)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		for _, name := range []string{
+			"activeDeadlineSeconds",
+			"backoffLimit",
+			"completions",
+			"parallelism",
+			"failedJobsHistoryLimit",
+			"successfulJobsHistoryLimit",
+			"ttlSecondsAfterFinished",
+		} {
+			assert.Contains(t, err.Error(), "spec."+name+": Invalid value: -1: must be greater than or equal to 0")
+		}
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("unsupported enum values", func(t *testing.T) {
+		warnings, errs := ValidateJobSpec(NaisjobSpec{
+			Schedule:          "@daily",
+			CompletionMode:    new("Sequential"),
+			RestartPolicy:     "Always",
+			ConcurrencyPolicy: "Queue",
+		}, path)
+		assert.Len(t, errs, 3)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.completionMode: Unsupported value: "Sequential": supported values: "NonIndexed", "Indexed"`)
+		assert.Contains(t, err.Error(), `spec.restartPolicy: Unsupported value: "Always": supported values: "OnFailure", "Never"`)
+		assert.Contains(t, err.Error(), `spec.concurrencyPolicy: Unsupported value: "Queue": supported values: "Allow", "Forbid", "Replace"`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("indexed completion mode without completions", func(t *testing.T) {
+		warnings, errs := ValidateJobSpec(NaisjobSpec{
+			CompletionMode: new("Indexed"),
+			Parallelism:    new(int32(maxIndexedJobCompletions + 1)),
+		}, path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.completions: Required value: when completion mode is Indexed")
+		assert.Contains(t, err.Error(), "spec.parallelism: Invalid value: 100001: must be less than or equal to 100000 when completion mode is Indexed")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("ttlSecondsAfterFinished longer than ttl", func(t *testing.T) {
+		warnings, errs := ValidateJobSpec(NaisjobSpec{
+			TTL:                     "1m",
+			TTLSecondsAfterFinished: new(int32(61)),
+		}, path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.ttlSecondsAfterFinished: Invalid value: 61: must not be longer than ttl (1m)")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("one-shot job with schedule settings and readiness probe", func(t *testing.T) {
+		warnings, errs := ValidateJobSpec(NaisjobSpec{
+			ConcurrencyPolicy: "Forbid",
+			TimeZone:          new("Europe/Oslo"),
+			Liveness:          &Probe{Path: "/alive"},
+			Readiness:         &Probe{Path: "/ready"},
+		}, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{
+			"spec.readiness: has no effect on one-shot Naisjobs, which do not receive traffic",
+			"spec.concurrencyPolicy: only applies to Naisjobs with a schedule",
+			"spec.timeZone: only applies to Naisjobs with a schedule",
+		}, warnings)
+	})
+}
@@ -0,0 +1,46 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func checkPostgresReference(ctx context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	postgres := req.Object.GetPostgres()
+	if postgres == nil || postgres.ClusterName == "" {
+		return nil, nil
+	}
+
+	pgMetaData := &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "data.nais.io/v1",
+			Kind:       "Postgres",
+		},
+	}
+	if err := req.Client.Get(ctx, client.ObjectKey{Name: postgres.ClusterName, Namespace: req.Object.GetNamespace()}, pgMetaData); err != nil {
+		if apierrors.IsNotFound(err) {
+			req.Logger.Info(fmt.Sprintf("Rejecting %s because the Postgres cluster does not exist", req.logKey()),
+				req.logKey(), req.Object.GetName(),
+				"namespace", req.Object.GetNamespace(),
+				"postgresCluster", postgres.ClusterName,
+			)
+			path := field.NewPath("spec", "postgres", "clusterName")
+			return nil, field.ErrorList{field.Invalid(path, postgres.ClusterName, fmt.Sprintf("Postgres '%s' does not exist. Create the Postgres cluster first.", postgres.ClusterName))}.ToAggregate()
+		}
+		req.Logger.Error(err, "internal error when validating Postgres reference")
+		return nil, apierrors.NewInternalError(fmt.Errorf("could not validate Postgres reference: %w", err))
+	}
+	return nil, nil
+}
+
+// logKey returns the key used for the workload name in log messages, e.g. `application`.
+func (r *WorkloadRequest) logKey() string {
+	return strings.ToLower(r.Kind)
+}
@@ -0,0 +1,107 @@
+package nais_io_v1
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Probes are the probes of a workload container.
+// +kubebuilder:object:generate=false
+type Probes struct {
+	Liveness  *Probe
+	Readiness *Probe
+	Startup   *Probe
+}
+
+// ValidateProbes checks that probes have an absolute path, and time out before the next probe is due.
+// The path is the parent of the probe fields, e.g. `spec`.
+//
+// Warnings are returned if a probe uses a port other than the given ports, as the container may listen on ports it does not declare,
+// if liveness and readiness are identical, as the container is then restarted whenever it is not ready,
+// or if the startup probe gives up before the initial delay of the other probes has passed.
+func ValidateProbes(probes Probes, ports []int, path *field.Path) (admission.Warnings, field.ErrorList) {
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+	for _, p := range []struct {
+		name  string
+		probe *Probe
+	}{
+		{"liveness", probes.Liveness},
+		{"readiness", probes.Readiness},
+		{"startup", probes.Startup},
+	} {
+		w, errs := validateProbe(p.probe, ports, path.Child(p.name))
+		warnings = append(warnings, w...)
+		allErrs = append(allErrs, errs...)
+	}
+
+	if probes.Liveness != nil && probes.Readiness != nil && len(probes.Liveness.Path) > 0 && *probes.Liveness == *probes.Readiness {
+		warnings = append(warnings, fmt.Sprintf("%s is identical to %s; the container is restarted whenever it is not ready", path.Child("liveness"), path.Child("readiness")))
+	}
+
+	if startup := probes.Startup; startup != nil && len(startup.Path) > 0 {
+		allowed := startup.InitialDelay + withDefault(startup.PeriodSeconds, DefaultProbePeriodSeconds)*withDefault(startup.FailureThreshold, DefaultProbeFailureThreshold)
+		for _, p := range []struct {
+			name  string
+			probe *Probe
+		}{
+			{"liveness", probes.Liveness},
+			{"readiness", probes.Readiness},
+		} {
+			if p.probe != nil && p.probe.InitialDelay > allowed {
+				warnings = append(warnings, fmt.Sprintf("%s allows %d seconds for startup, which is less than the initial delay of %s (%d seconds)", path.Child("startup"), allowed, path.Child(p.name), p.probe.InitialDelay))
+			}
+		}
+	}
+
+	return warnings, allErrs
+}
+
+// validateProbe checks a single probe. Probes without a path are not used, and are not checked.
+func validateProbe(probe *Probe, ports []int, path *field.Path) (admission.Warnings, field.ErrorList) {
+	if probe == nil || len(probe.Path) == 0 {
+		return nil, nil
+	}
+
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+	if !strings.HasPrefix(probe.Path, "/") {
+		allErrs = append(allErrs, field.Invalid(path.Child("path"), probe.Path, "must be an absolute path"))
+	}
+	if probe.Port != 0 && !slices.Contains(ports, probe.Port) {
+		warnings = append(warnings, fmt.Sprintf("%s: %d is not one of the ports of the application (%v); the probe fails unless the container listens on it", path.Child("port"), probe.Port, ports))
+	}
+
+	for _, f := range []struct {
+		name  string
+		value int
+	}{
+		{"initialDelay", probe.InitialDelay},
+		{"periodSeconds", probe.PeriodSeconds},
+		{"failureThreshold", probe.FailureThreshold},
+		{"timeout", probe.Timeout},
+	} {
+		if f.value < 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child(f.name), f.value, "must be greater than or equal to 0"))
+		}
+	}
+
+	period := withDefault(probe.PeriodSeconds, DefaultProbePeriodSeconds)
+	if timeout := withDefault(probe.Timeout, DefaultProbeTimeoutSeconds); timeout > period {
+		allErrs = append(allErrs, field.Invalid(path.Child("timeout"), timeout, fmt.Sprintf("must be less than or equal to periodSeconds (%d)", period)))
+	}
+
+	return warnings, allErrs
+}
+
+// withDefault returns the default value if the value is not set.
+func withDefault(value, def int) int {
+	if value <= 0 {
+		return def
+	}
+	return value
+}
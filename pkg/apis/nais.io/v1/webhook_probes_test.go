@@ -0,0 +1,95 @@
+package nais_io_v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateProbes(t *testing.T) {
+	ports := []int{8080, 9090}
+	path := field.NewPath("spec")
+
+	t.Run("valid probes", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Liveness:  &Probe{Path: "/isalive", Port: 8080, InitialDelay: 20},
+			Readiness: &Probe{Path: "/isready", Port: 9090, Timeout: 5, PeriodSeconds: 5},
+			Startup:   &Probe{Path: "/started", PeriodSeconds: 10, FailureThreshold: 3},
+		}, ports, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("probes without path are not used", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Liveness: &Probe{Port: 1234, Timeout: 20},
+		}, ports, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("relative path", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Liveness: &Probe{Path: "isalive"},
+		}, ports, path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.liveness.path: Invalid value: "isalive": must be an absolute path`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("undeclared port", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Readiness: &Probe{Path: "/isready", Port: 8081},
+		}, ports, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{"spec.readiness.port: 8081 is not one of the ports of the application ([8080 9090]); the probe fails unless the container listens on it"}, warnings)
+	})
+
+	t.Run("timeout longer than period", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Liveness: &Probe{Path: "/isalive", Timeout: 11},
+			Startup:  &Probe{Path: "/started", Timeout: 5, PeriodSeconds: 2},
+		}, ports, path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.liveness.timeout: Invalid value: 11: must be less than or equal to periodSeconds (10)")
+		assert.Contains(t, err.Error(), "spec.startup.timeout: Invalid value: 5: must be less than or equal to periodSeconds (2)")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("negative values", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Readiness: &Probe{Path: "/isready", InitialDelay: -1, FailureThreshold: -1},
+		}, ports, path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.readiness.initialDelay: Invalid value: -1: must be greater than or equal to 0")
+		assert.Contains(t, err.Error(), "spec.readiness.failureThreshold: Invalid value: -1: must be greater than or equal to 0")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("identical liveness and readiness", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Liveness:  &Probe{Path: "/health"},
+			Readiness: &Probe{Path: "/health"},
+		}, ports, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{"spec.liveness is identical to spec.readiness; the container is restarted whenever it is not ready"}, warnings)
+	})
+
+	t.Run("startup gives up before initial delay", func(t *testing.T) {
+		warnings, errs := ValidateProbes(Probes{
+			Liveness:  &Probe{Path: "/isalive", InitialDelay: 60},
+			Readiness: &Probe{Path: "/isready", InitialDelay: 20},
+			Startup:   &Probe{Path: "/started", PeriodSeconds: 5, FailureThreshold: 6},
+		}, ports, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{"spec.startup allows 30 seconds for startup, which is less than the initial delay of spec.liveness (60 seconds)"}, warnings)
+	})
+}
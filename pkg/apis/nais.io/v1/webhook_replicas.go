@@ -0,0 +1,127 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	kafka_nais_io_v1 "github.com/nais/liberator/pkg/apis/kafka.nais.io/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// maxStabilizationWindowSeconds is the longest stabilization window accepted by the HorizontalPodAutoscaler.
+const maxStabilizationWindowSeconds = 3600
+
+// ValidateReplicas checks that the replica range and the autoscaling thresholds are consistent.
+// A warning is returned if both the deprecated CpuThresholdPercentage and ScalingStrategy.Cpu are set,
+// as the former is then ignored.
+func ValidateReplicas(replicas *Replicas, path *field.Path) (admission.Warnings, field.ErrorList) {
+	if replicas == nil {
+		return nil, nil
+	}
+
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+
+	if replicas.Min != nil && *replicas.Min < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("min"), *replicas.Min, "must be greater than or equal to 0"))
+	}
+	if replicas.Max != nil && *replicas.Max < 0 {
+		allErrs = append(allErrs, field.Invalid(path.Child("max"), *replicas.Max, "must be greater than or equal to 0"))
+	}
+	if replicas.Min != nil && replicas.Max != nil && *replicas.Min > *replicas.Max {
+		allErrs = append(allErrs, field.Invalid(path.Child("min"), *replicas.Min, fmt.Sprintf("must be less than or equal to max (%d)", *replicas.Max)))
+	}
+
+	if replicas.CpuThresholdPercentage != 0 {
+		allErrs = append(allErrs, validatePercentage(replicas.CpuThresholdPercentage, path.Child("cpuThresholdPercentage"))...)
+	}
+
+	strategy := replicas.ScalingStrategy
+	if strategy == nil {
+		return warnings, allErrs
+	}
+	strategyPath := path.Child("scalingStrategy")
+
+	if strategy.Cpu != nil {
+		allErrs = append(allErrs, validatePercentage(strategy.Cpu.ThresholdPercentage, strategyPath.Child("cpu", "thresholdPercentage"))...)
+		if replicas.CpuThresholdPercentage != 0 {
+			warnings = append(warnings, fmt.Sprintf("%s: ignored, as %s is set", path.Child("cpuThresholdPercentage"), strategyPath.Child("cpu")))
+		}
+	}
+
+	if kafka := strategy.Kafka; kafka != nil {
+		kafkaPath := strategyPath.Child("kafka")
+		if len(kafka.Topic) == 0 {
+			allErrs = append(allErrs, field.Required(kafkaPath.Child("topic"), ""))
+		}
+		if len(kafka.ConsumerGroup) == 0 {
+			allErrs = append(allErrs, field.Required(kafkaPath.Child("consumerGroup"), ""))
+		}
+		if kafka.Threshold < 1 {
+			allErrs = append(allErrs, field.Invalid(kafkaPath.Child("threshold"), kafka.Threshold, "must be greater than 0"))
+		}
+	}
+
+	for _, window := range []struct {
+		name    string
+		seconds int
+	}{
+		{"scaleUpStabilizationWindowSeconds", strategy.ScaleUpStabilizationWindowSeconds},
+		{"scaleDownStabilizationWindowSeconds", strategy.ScaleDownStabilizationWindowSeconds},
+	} {
+		if window.seconds < 0 || window.seconds > maxStabilizationWindowSeconds {
+			allErrs = append(allErrs, field.Invalid(strategyPath.Child(window.name), window.seconds, fmt.Sprintf("must be between 0 and %d", maxStabilizationWindowSeconds)))
+		}
+	}
+
+	return warnings, allErrs
+}
+
+// ValidateKafkaScalingTopic checks that the topic used for Kafka lag based scaling exists,
+// is in the Kafka pool of the workload, and that the workload is granted read access to it.
+// The topic is named `<namespace>.<name>`, like the topic in Kafka.
+// Use a reader without a cache, e.g. the API reader of a manager, as a cache would watch every Topic in the cluster.
+// The error is non-nil if the topic could not be looked up.
+func ValidateKafkaScalingTopic(ctx context.Context, reader client.Reader, scaling *KafkaScaling, kafka *Kafka, team, application string, path *field.Path) (field.ErrorList, error) {
+	if scaling == nil || len(scaling.Topic) == 0 {
+		return nil, nil
+	}
+	if kafka == nil {
+		return field.ErrorList{field.Invalid(path, scaling.Topic, "requires spec.kafka to be set")}, nil
+	}
+
+	namespace, name, ok := strings.Cut(scaling.Topic, ".")
+	if !ok {
+		return field.ErrorList{field.Invalid(path, scaling.Topic, "must be the full name of the topic, i.e. <namespace>.<name>")}, nil
+	}
+
+	topic := &kafka_nais_io_v1.Topic{}
+	if err := reader.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, topic); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.ErrorList{field.Invalid(path, scaling.Topic, "topic does not exist")}, nil
+		}
+		return nil, apierrors.NewInternalError(fmt.Errorf("could not validate Kafka scaling topic: %w", err))
+	}
+
+	if topic.Spec.Pool != kafka.Pool {
+		return field.ErrorList{field.Invalid(path, scaling.Topic, fmt.Sprintf("topic is in pool %q, not %q", topic.Spec.Pool, kafka.Pool))}, nil
+	}
+	// Access defaults to readwrite
+	for _, acl := range topic.Spec.ACL {
+		if (acl.Access == "" || acl.Access == "read" || acl.Access == "readwrite") && aclMatches(acl.Team, team) && aclMatches(acl.Application, application) {
+			return nil, nil
+		}
+	}
+	return field.ErrorList{field.Invalid(path, scaling.Topic, fmt.Sprintf("%s is not granted read access to the topic", application))}, nil
+}
+
+func validatePercentage(value int, path *field.Path) field.ErrorList {
+	if value < 1 || value > 100 {
+		return field.ErrorList{field.Invalid(path, value, "must be between 1 and 100")}
+	}
+	return nil
+}
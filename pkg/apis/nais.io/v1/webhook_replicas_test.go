@@ -0,0 +1,154 @@
+package nais_io_v1
+
+import (
+	"testing"
+
+	kafka_nais_io_v1 "github.com/nais/liberator/pkg/apis/kafka.nais.io/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateReplicas(t *testing.T) {
+	path := field.NewPath("spec", "replicas")
+
+	t.Run("valid replicas", func(t *testing.T) {
+		warnings, errs := ValidateReplicas(&Replicas{
+			Min: new(2),
+			Max: new(4),
+			ScalingStrategy: &ScalingStrategy{
+				Cpu:                                 &CpuScaling{ThresholdPercentage: 50},
+				Kafka:                               &KafkaScaling{Topic: "myteam.mytopic", ConsumerGroup: "mygroup", Threshold: 10},
+				ScaleDownStabilizationWindowSeconds: 300,
+			},
+		}, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("scaled to zero", func(t *testing.T) {
+		warnings, errs := ValidateReplicas(&Replicas{Min: new(0), Max: new(0)}, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("min greater than max", func(t *testing.T) {
+		warnings, errs := ValidateReplicas(&Replicas{Min: new(4), Max: new(2)}, path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.replicas.min: Invalid value: 4: must be less than or equal to max (2)")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("negative replicas", func(t *testing.T) {
+		warnings, errs := ValidateReplicas(&Replicas{Min: new(-1), Max: new(-1)}, path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.replicas.min: Invalid value: -1: must be greater than or equal to 0")
+		assert.Contains(t, err.Error(), "spec.replicas.max: Invalid value: -1: must be greater than or equal to 0")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("thresholds out of range", func(t *testing.T) {
+		warnings, errs := ValidateReplicas(&Replicas{
+			CpuThresholdPercentage: 120,
+			ScalingStrategy: &ScalingStrategy{
+				Cpu:                               &CpuScaling{},
+				Kafka:                             &KafkaScaling{},
+				ScaleUpStabilizationWindowSeconds: 3601,
+			},
+		}, path)
+		assert.Len(t, errs, 6)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.replicas.cpuThresholdPercentage: Invalid value: 120: must be between 1 and 100")
+		assert.Contains(t, err.Error(), "spec.replicas.scalingStrategy.cpu.thresholdPercentage: Invalid value: 0: must be between 1 and 100")
+		assert.Contains(t, err.Error(), "spec.replicas.scalingStrategy.kafka.topic: Required value")
+		assert.Contains(t, err.Error(), "spec.replicas.scalingStrategy.kafka.consumerGroup: Required value")
+		assert.Contains(t, err.Error(), "spec.replicas.scalingStrategy.kafka.threshold: Invalid value: 0: must be greater than 0")
+		assert.Contains(t, err.Error(), "spec.replicas.scalingStrategy.scaleUpStabilizationWindowSeconds: Invalid value: 3601: must be between 0 and 3600")
+		assert.Equal(t, admission.Warnings{"spec.replicas.cpuThresholdPercentage: ignored, as spec.replicas.scalingStrategy.cpu is set"}, warnings)
+	})
+
+	t.Run("deprecated and new cpu threshold", func(t *testing.T) {
+		warnings, errs := ValidateReplicas(&Replicas{
+			CpuThresholdPercentage: 50,
+			ScalingStrategy:        &ScalingStrategy{Cpu: &CpuScaling{ThresholdPercentage: 70}},
+		}, path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{"spec.replicas.cpuThresholdPercentage: ignored, as spec.replicas.scalingStrategy.cpu is set"}, warnings)
+	})
+}
+
+func TestValidateKafkaScalingTopic(t *testing.T) {
+	topic := &kafka_nais_io_v1.Topic{
+		ObjectMeta: metav1.ObjectMeta{Name: "mytopic", Namespace: "myteam"},
+		Spec: kafka_nais_io_v1.TopicSpec{
+			Pool: "nav-dev",
+			ACL: []kafka_nais_io_v1.TopicACL{
+				{Team: "myteam", Application: "reader", Access: "read"},
+				{Team: "myteam", Application: "writer", Access: "write"},
+				{Team: "other*", Application: "*", Access: "readwrite"},
+			},
+		},
+	}
+	reader := fakeKubeClient(topic)
+	kafka := &Kafka{Pool: "nav-dev"}
+	path := field.NewPath("spec", "replicas", "scalingStrategy", "kafka", "topic")
+	scaling := func(topic string) *KafkaScaling {
+		return &KafkaScaling{Topic: topic, ConsumerGroup: "group", Threshold: 10}
+	}
+
+	t.Run("read access", func(t *testing.T) {
+		errs, err := ValidateKafkaScalingTopic(t.Context(), reader, scaling("myteam.mytopic"), kafka, "myteam", "reader", path)
+		require.NoError(t, err)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	t.Run("read access with wildcard", func(t *testing.T) {
+		errs, err := ValidateKafkaScalingTopic(t.Context(), reader, scaling("myteam.mytopic"), kafka, "otherteam", "consumer", path)
+		require.NoError(t, err)
+		assert.NoError(t, errs.ToAggregate())
+	})
+
+	for name, tt := range map[string]struct {
+		topic       string
+		kafka       *Kafka
+		application string
+		expected    string
+	}{
+		"write access only": {
+			topic: "myteam.mytopic", kafka: kafka, application: "writer",
+			expected: `spec.replicas.scalingStrategy.kafka.topic: Invalid value: "myteam.mytopic": writer is not granted read access to the topic`,
+		},
+		"topic does not exist": {
+			topic: "myteam.missing", kafka: kafka, application: "reader",
+			expected: `spec.replicas.scalingStrategy.kafka.topic: Invalid value: "myteam.missing": topic does not exist`,
+		},
+		"topic without namespace": {
+			topic: "mytopic", kafka: kafka, application: "reader",
+			expected: `spec.replicas.scalingStrategy.kafka.topic: Invalid value: "mytopic": must be the full name of the topic, i.e. <namespace>.<name>`,
+		},
+		"kafka not enabled": {
+			topic: "myteam.mytopic", application: "reader",
+			expected: `spec.replicas.scalingStrategy.kafka.topic: Invalid value: "myteam.mytopic": requires spec.kafka to be set`,
+		},
+		"topic in another pool": {
+			topic: "myteam.mytopic", kafka: &Kafka{Pool: "nav-prod"}, application: "reader",
+			expected: `spec.replicas.scalingStrategy.kafka.topic: Invalid value: "myteam.mytopic": topic is in pool "nav-dev", not "nav-prod"`,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			errs, err := ValidateKafkaScalingTopic(t.Context(), reader, scaling(tt.topic), tt.kafka, "myteam", tt.application, path)
+			require.NoError(t, err)
+			assert.Len(t, errs, 1)
+			err = errs.ToAggregate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expected)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// JVMImageMarkers are segments of image names that indicate that the image runs a JVM.
+// Image names are split into segments on any of the characters in jvmImageSeparators.
+var JVMImageMarkers = []string{"java", "jdk", "openjdk", "jre", "temurin", "corretto", "zulu"}
+
+const jvmImageSeparators = "/:-_."
+
+// MinJVMMemory is the smallest memory limit that does not give a warning for JVM images.
+var MinJVMMemory = resource.MustParse("256Mi")
+
+// WithMaxResources rejects workloads with resource requests or limits larger than the given quantities.
+// A zero quantity does not limit that resource.
+func WithMaxResources(cpu, memory resource.Quantity) ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.MaxCPU = cpu
+		cfg.MaxMemory = memory
+	}
+}
+
+func checkResources(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	warnings, allErrs := req.Config.ValidateResources(req.Object.GetResources(), req.Object.GetImage(), field.NewPath("spec", "resources"))
+	return warnings, allErrs.ToAggregate()
+}
+
+// ValidateResources checks that resource requests and limits are valid quantities, that no request is larger
+// than its limit, and that neither is larger than the maximum configured for the cluster.
+// A warning is returned if the image looks like it runs a JVM, and the memory limit is less than MinJVMMemory.
+func (cfg ValidatorConfig) ValidateResources(resources *ResourceRequirements, image string, path *field.Path) (admission.Warnings, field.ErrorList) {
+	if resources == nil {
+		return nil, nil
+	}
+
+	var allErrs field.ErrorList
+	requests, errs := cfg.parseResources(resources.Requests, path.Child("requests"))
+	allErrs = append(allErrs, errs...)
+	limits, errs := cfg.parseResources(resources.Limits, path.Child("limits"))
+	allErrs = append(allErrs, errs...)
+
+	for _, name := range []string{"cpu", "memory"} {
+		request, limit := requests[name], limits[name]
+		if request != nil && limit != nil && request.Cmp(*limit) > 0 {
+			allErrs = append(allErrs, field.Invalid(path.Child("requests", name), request.String(), fmt.Sprintf("must be less than or equal to the limit (%s)", limit)))
+		}
+	}
+
+	var warnings admission.Warnings
+	memory, memoryPath := limits["memory"], path.Child("limits", "memory")
+	if memory == nil {
+		memory, memoryPath = requests["memory"], path.Child("requests", "memory")
+	}
+	if memory != nil && memory.Cmp(MinJVMMemory) < 0 && isJVMImage(image) {
+		warnings = append(warnings, fmt.Sprintf("%s: %s of memory is likely too little for a JVM; consider at least %s", memoryPath, memory, &MinJVMMemory))
+	}
+
+	return warnings, allErrs
+}
+
+// parseResources parses the quantities of a ResourceSpec, keyed by their JSON names.
+// Quantities that are not set or not valid are left out.
+func (cfg ValidatorConfig) parseResources(spec *ResourceSpec, path *field.Path) (map[string]*resource.Quantity, field.ErrorList) {
+	quantities := map[string]*resource.Quantity{}
+	if spec == nil {
+		return quantities, nil
+	}
+
+	var allErrs field.ErrorList
+	for _, r := range []struct {
+		name  string
+		value string
+		max   resource.Quantity
+	}{
+		{"cpu", spec.Cpu, cfg.MaxCPU},
+		{"memory", spec.Memory, cfg.MaxMemory},
+	} {
+		if len(r.value) == 0 {
+			continue
+		}
+
+		q, err := resource.ParseQuantity(r.value)
+		switch {
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(path.Child(r.name), r.value, "must be a valid quantity, e.g. 500m or 512Mi"))
+			continue
+		case q.Sign() < 0:
+			allErrs = append(allErrs, field.Invalid(path.Child(r.name), r.value, "must not be negative"))
+			continue
+		case !r.max.IsZero() && q.Cmp(r.max) > 0:
+			allErrs = append(allErrs, field.Invalid(path.Child(r.name), r.value, fmt.Sprintf("must be less than or equal to %s", &r.max)))
+		}
+		quantities[r.name] = &q
+	}
+	return quantities, allErrs
+}
+
+func isJVMImage(image string) bool {
+	segments := strings.FieldsFunc(strings.ToLower(image), func(r rune) bool {
+		return strings.ContainsRune(jvmImageSeparators, r)
+	})
+	for _, marker := range JVMImageMarkers {
+		if slices.Contains(segments, marker) {
+			return true
+		}
+	}
+	return false
+}
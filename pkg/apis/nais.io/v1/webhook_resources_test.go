@@ -0,0 +1,89 @@
+package nais_io_v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidateResources(t *testing.T) {
+	cfg := NewValidatorConfig(WithMaxResources(resource.MustParse("4"), resource.MustParse("8Gi")))
+	path := field.NewPath("spec", "resources")
+
+	t.Run("valid resources", func(t *testing.T) {
+		warnings, errs := cfg.ValidateResources(&ResourceRequirements{
+			Requests: &ResourceSpec{Cpu: "200m", Memory: "256Mi"},
+			Limits:   &ResourceSpec{Cpu: "1", Memory: "512Mi"},
+		}, "", path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("malformed quantities", func(t *testing.T) {
+		warnings, errs := cfg.ValidateResources(&ResourceRequirements{
+			Requests: &ResourceSpec{Cpu: "lots", Memory: "-1Mi"},
+			Limits:   &ResourceSpec{Memory: "512MB!"},
+		}, "", path)
+		assert.Len(t, errs, 3)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.resources.requests.cpu: Invalid value: "lots": must be a valid quantity, e.g. 500m or 512Mi`)
+		assert.Contains(t, err.Error(), `spec.resources.requests.memory: Invalid value: "-1Mi": must not be negative`)
+		assert.Contains(t, err.Error(), `spec.resources.limits.memory: Invalid value: "512MB!": must be a valid quantity, e.g. 500m or 512Mi`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("requests greater than limits", func(t *testing.T) {
+		warnings, errs := cfg.ValidateResources(&ResourceRequirements{
+			Requests: &ResourceSpec{Cpu: "2", Memory: "1Gi"},
+			Limits:   &ResourceSpec{Cpu: "1500m", Memory: "1024Mi"},
+		}, "", path)
+		assert.Len(t, errs, 1)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.resources.requests.cpu: Invalid value: "2": must be less than or equal to the limit (1500m)`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("above cluster maximum", func(t *testing.T) {
+		warnings, errs := cfg.ValidateResources(&ResourceRequirements{
+			Requests: &ResourceSpec{Cpu: "8"},
+			Limits:   &ResourceSpec{Memory: "16Gi"},
+		}, "", path)
+		assert.Len(t, errs, 2)
+		err := errs.ToAggregate()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.resources.requests.cpu: Invalid value: "8": must be less than or equal to 4`)
+		assert.Contains(t, err.Error(), `spec.resources.limits.memory: Invalid value: "16Gi": must be less than or equal to 8Gi`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("little memory for a JVM image", func(t *testing.T) {
+		warnings, errs := cfg.ValidateResources(&ResourceRequirements{
+			Limits: &ResourceSpec{Memory: "128Mi"},
+		}, "ghcr.io/navikt/myapp/eclipse-temurin:21-jre", path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{"spec.resources.limits.memory: 128Mi of memory is likely too little for a JVM; consider at least 256Mi"}, warnings)
+	})
+
+	t.Run("little memory requested for a JVM image", func(t *testing.T) {
+		warnings, errs := cfg.ValidateResources(&ResourceRequirements{
+			Requests: &ResourceSpec{Memory: "64Mi"},
+		}, "europe-north1-docker.pkg.dev/nais/myapp:java_21", path)
+		assert.NoError(t, errs.ToAggregate())
+		assert.Equal(t, admission.Warnings{"spec.resources.requests.memory: 64Mi of memory is likely too little for a JVM; consider at least 256Mi"}, warnings)
+	})
+
+	t.Run("little memory for another image", func(t *testing.T) {
+		for _, image := range []string{"ghcr.io/navikt/myapp:latest", "ghcr.io/navikt/javascript-app:latest", "ghcr.io/navikt/jrebel:1"} {
+			warnings, errs := cfg.ValidateResources(&ResourceRequirements{
+				Limits: &ResourceSpec{Memory: "128Mi"},
+			}, image, path)
+			assert.NoError(t, errs.ToAggregate())
+			assert.Empty(t, warnings, image)
+		}
+	})
+}
@@ -1,20 +1,26 @@
 package nais_io_v1
 
 import (
+	"context"
+	"encoding/json"
 	"strconv"
 	"testing"
 	"time"
 
 	aiven_io_v1alpha1 "github.com/nais/liberator/pkg/apis/aiven.io/v1alpha1"
 	aiven_nais_io_v1 "github.com/nais/liberator/pkg/apis/aiven.nais.io/v1"
+	kafka_nais_io_v1 "github.com/nais/liberator/pkg/apis/kafka.nais.io/v1"
 	data_nais_io_v1 "github.com/nais/pgrator/pkg/api/datav1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 func fakeKubeClient(objs ...client.Object) client.Client {
@@ -23,10 +29,14 @@ func fakeKubeClient(objs ...client.Object) client.Client {
 	// Add necessary schemes for the test
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = aiven_io_v1alpha1.AddToScheme(scheme)
+	_ = kafka_nais_io_v1.AddToScheme(scheme)
 	_ = data_nais_io_v1.AddToScheme(scheme)
 	_ = AddToScheme(scheme)
 
-	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	return fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(objs...).
+		WithIndex(&kafka_nais_io_v1.Topic{}, TopicPoolIndex, TopicPool).
+		Build()
 }
 
 func TestJobValidator_ValidateCreate(t *testing.T) {
@@ -67,6 +77,43 @@ func TestJobValidator_ValidateCreate(t *testing.T) {
 		assert.Empty(t, warnings)
 	})
 
+	t.Run("all problems are reported together", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "this-is-a-very-long-name-that-exceeds-the-maximum-allowed-length-for-kubernetes-label-values-which-is-63-characters",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:         "nginx:latest",
+				Schedule:      "0 * * * *",
+				TTL:           "tomorrow",
+				RestartPolicy: "Always",
+				OpenSearch:    &OpenSearch{Instance: "nonexistent-opensearch"},
+				Valkey:        []Valkey{{Instance: "nonexistent-valkey"}},
+				Postgres:      &Postgres{ClusterName: "nonexistent-postgres"},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Empty(t, warnings)
+
+		var paths []string
+		for _, cause := range err.(apierrors.APIStatus).Status().Details.Causes {
+			paths = append(paths, cause.Field)
+		}
+		assert.ElementsMatch(t, []string{
+			"metadata.name",
+			"spec.ttl",
+			"spec.restartPolicy",
+			"spec.openSearch.instance",
+			"spec.valkey[0].instance",
+			"spec.postgres.clusterName",
+		}, paths)
+	})
+
 	t.Run("valid TTL duration", func(t *testing.T) {
 		validator := &JobValidator{Client: fakeKubeClient()}
 		ttl := "12h"
@@ -108,6 +155,196 @@ func TestJobValidator_ValidateCreate(t *testing.T) {
 		assert.Empty(t, warnings)
 	})
 
+	t.Run("TTL above maximum", func(t *testing.T) {
+		validator := &JobValidator{
+			Client: fakeKubeClient(),
+			config: NewValidatorConfig(WithMaxTTL(24 * time.Hour)),
+		}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				TTL:      "48h",
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.ttl: Invalid value: \"48h\": must be no longer than 24h0m0s")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("TTL gives warning with deletion time", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+				Labels: map[string]string{
+					LabelKillAfter: "1735732800",
+				},
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				TTL:      "1h",
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.NoError(t, err)
+		assert.Equal(t, admission.Warnings{"Naisjob test-job will be deleted at 2025-01-01T12:00:00Z, when its TTL of 1h expires"}, warnings)
+	})
+
+	t.Run("invalid cron schedule", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * *",
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.schedule")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("invalid time zone", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		for _, timeZone := range []string{"", "Local", "Europe/Bergen"} {
+			nj := &Naisjob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-job",
+					Namespace: "test-ns",
+				},
+				Spec: NaisjobSpec{
+					Image:    "nginx:latest",
+					Schedule: "@hourly",
+					TimeZone: &timeZone,
+				},
+			}
+
+			warnings, err := validator.ValidateCreate(t.Context(), nj)
+			assert.Error(t, err, timeZone)
+			assert.Contains(t, err.Error(), "spec.timeZone")
+			assert.Empty(t, warnings)
+		}
+	})
+
+	t.Run("schedule with an interval", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "@every 10s",
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.schedule: Invalid value: "@every 10s": intervals using @every are not supported`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("suspicious schedules give warnings", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		for schedule, expected := range map[string]string{
+			"0 0 30 2 *": "spec.schedule: the schedule never runs",
+			"30 6 * * *": "",
+		} {
+			nj := &Naisjob{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-job",
+					Namespace: "test-ns",
+				},
+				Spec: NaisjobSpec{
+					Image:    "nginx:latest",
+					Schedule: schedule,
+					TimeZone: new("Europe/Oslo"),
+				},
+			}
+
+			warnings, err := validator.ValidateCreate(t.Context(), nj)
+			assert.NoError(t, err)
+			if expected == "" {
+				assert.Empty(t, warnings, schedule)
+				continue
+			}
+			if assert.Len(t, warnings, 1, schedule) {
+				assert.Contains(t, warnings[0], expected)
+			}
+		}
+	})
+
+	t.Run("deprecated fields give warnings", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				Azure: &AzureNaisJob{
+					Application: &AzureApplication{
+						Enabled:   true,
+						ReplyURLs: []AzureAdReplyUrlString{"https://example.com/callback"},
+						Claims: &AzureAdClaims{
+							Extra: []AzureAdExtraClaim{"NAVident"},
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.NoError(t, err)
+		require.Len(t, warnings, 2)
+		assert.Contains(t, warnings[0], "spec.azure.application.replyURLs is deprecated: ")
+		assert.Contains(t, warnings[1], "spec.azure.application.claims.extra is deprecated: ")
+	})
+
+	t.Run("mutually exclusive env value and valueFrom", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				Env: EnvVars{
+					{
+						Name:      "FOO",
+						Value:     "bar",
+						ValueFrom: &EnvVarSource{FieldRef: ObjectFieldSelector{FieldPath: "metadata.name"}},
+					},
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.env[0].valueFrom")
+		assert.Empty(t, warnings)
+	})
+
 	t.Run("opensearch reference exists", func(t *testing.T) {
 		namespace := "test-ns"
 		instance := "my-opensearch"
@@ -352,6 +589,205 @@ func TestJobValidator_ValidateCreate(t *testing.T) {
 		assert.Contains(t, err.Error(), "Postgres 'no-such-postgres' does not exist")
 		assert.Empty(t, warnings)
 	})
+
+	t.Run("invalid valkey access level", func(t *testing.T) {
+		valkey := &aiven_io_v1alpha1.Valkey{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      aiven_nais_io_v1.ValkeyFullyQualifiedName("my-valkey", "test-ns"),
+				Namespace: "test-ns",
+			},
+		}
+
+		validator := &JobValidator{Client: fakeKubeClient(valkey)}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				Valkey: []Valkey{
+					{Instance: "my-valkey", Access: "readwrite"},
+					{Instance: "my-valkey", Access: "superuser"},
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), "spec.valkey[1].access")
+		assert.NotContains(t, err.Error(), "spec.valkey[0]")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("opensearch owned by another team", func(t *testing.T) {
+		opensearch := &aiven_io_v1alpha1.OpenSearch{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      aiven_nais_io_v1.OpenSearchFullyQualifiedName("my-opensearch", "test-ns"),
+				Namespace: "test-ns",
+			},
+			Spec: aiven_io_v1alpha1.OpenSearchSpec{
+				ServiceCommonSpec: aiven_io_v1alpha1.ServiceCommonSpec{
+					Tags: map[string]string{"team": "other-team"},
+				},
+			},
+		}
+
+		validator := &JobValidator{Client: fakeKubeClient(opensearch)}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				OpenSearch: &OpenSearch{
+					Instance: "my-opensearch",
+					Access:   "admin",
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.openSearch.instance")
+		assert.Contains(t, err.Error(), `owned by team "other-team"`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("unknown kafka pool", func(t *testing.T) {
+		validator := &JobValidator{
+			Client: fakeKubeClient(),
+			config: NewValidatorConfig(WithKafkaPools("nav-dev", "nav-infrastructure")),
+		}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				Kafka:    &Kafka{Pool: "nav-dve"},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.kafka.pool")
+		assert.Contains(t, err.Error(), `"nav-dev", "nav-infrastructure"`)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("kafka topic acl warnings", func(t *testing.T) {
+		topic := func(name, pool string, acls ...kafka_nais_io_v1.TopicACL) *kafka_nais_io_v1.Topic {
+			return &kafka_nais_io_v1.Topic{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "other-ns"},
+				Spec:       kafka_nais_io_v1.TopicSpec{Pool: pool, ACL: acls},
+			}
+		}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				Kafka:    &Kafka{Pool: "nav-dev"},
+			},
+		}
+
+		for name, tt := range map[string]struct {
+			topics []client.Object
+			warns  bool
+		}{
+			"no topics": {
+				warns: true,
+			},
+			"granted in another pool": {
+				topics: []client.Object{topic("a", "nav-prod", kafka_nais_io_v1.TopicACL{Team: "test-ns", Application: "test-job", Access: "read"})},
+				warns:  true,
+			},
+			"granted explicitly": {
+				topics: []client.Object{topic("a", "nav-dev", kafka_nais_io_v1.TopicACL{Team: "test-ns", Application: "test-job", Access: "read"})},
+			},
+			"granted by wildcard": {
+				topics: []client.Object{topic("a", "nav-dev", kafka_nais_io_v1.TopicACL{Team: "test-ns", Application: "*", Access: "read"})},
+			},
+		} {
+			t.Run(name, func(t *testing.T) {
+				validator := &JobValidator{
+					Client: fakeKubeClient(tt.topics...),
+					config: NewValidatorConfig(WithTopicACLWarnings()),
+				}
+
+				warnings, err := validator.ValidateCreate(t.Context(), nj)
+				assert.NoError(t, err)
+				if tt.warns {
+					require.Len(t, warnings, 1)
+					assert.Contains(t, warnings[0], `spec.kafka: test-job is not granted access to any Topic in pool "nav-dev"`)
+				} else {
+					assert.Empty(t, warnings)
+				}
+			})
+		}
+	})
+
+	t.Run("wildcard inbound rule with azure application", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient(), config: NewValidatorConfig(WithInboundWildcards())}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				Azure:    &AzureNaisJob{Application: &AzureApplication{Enabled: true}},
+				AccessPolicy: &AccessPolicy{Inbound: &AccessPolicyInbound{Rules: []AccessPolicyInboundRule{
+					{AccessPolicyRule: AccessPolicyRule{Application: "*", Namespace: "*", Team: "team"}},
+				}}},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.inbound.rules[0]: Forbidden: wildcards and team selectors cannot be used with spec.azure.application, as inbound rules also grant access to tokens")
+		assert.Empty(t, warnings)
+
+		nj.Spec.Azure = nil
+		warnings, err = validator.ValidateCreate(t.Context(), nj)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("external rule without a target", func(t *testing.T) {
+		validator := &JobValidator{Client: fakeKubeClient()}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				AccessPolicy: &AccessPolicy{Outbound: &AccessPolicyOutbound{External: []AccessPolicyExternalRule{
+					{Host: "example.com"},
+					{Ports: []AccessPolicyPortRule{{Port: 443}}},
+				}}},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), nj)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.outbound.external[1]: Required value: exactly one of host, ipv4, ipv6, cidr must be set")
+		assert.NotContains(t, err.Error(), "external[0]")
+		assert.Empty(t, warnings)
+	})
 }
 
 func TestJobValidator_ValidateUpdate(t *testing.T) {
@@ -453,6 +889,27 @@ func TestJobValidator_ValidateUpdate(t *testing.T) {
 		assert.Empty(t, warnings)
 	})
 
+	t.Run("update of one-shot job with readiness probe", func(t *testing.T) {
+		// Existing one-shot Naisjobs may have a readiness probe, which must not block further updates
+		validator := &JobValidator{Client: fakeKubeClient()}
+		oldNj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:     "nginx:latest",
+				Readiness: &Probe{Path: "/ready"},
+			},
+		}
+		newNj := oldNj.DeepCopy()
+		newNj.Spec.Image = "nginx:1.29"
+
+		warnings, err := validator.ValidateUpdate(t.Context(), oldNj, newNj)
+		assert.NoError(t, err)
+		assert.Equal(t, admission.Warnings{"spec.readiness: has no effect on one-shot Naisjobs, which do not receive traffic"}, warnings)
+	})
+
 	t.Run("update with deletion timestamp skips validation", func(t *testing.T) {
 		// Finalizer removal goes through the update admission path. Validation
 		// must short-circuit so missing references do not block deletion.
@@ -580,6 +1037,134 @@ func TestJobMutator_Default(t *testing.T) {
 		assert.NotEqual(t, existingTime, nj.Labels[LabelKillAfter], "kill-after label should be overwritten")
 	})
 
+	t.Run("keeps kill-after label when TTL is unchanged", func(t *testing.T) {
+		mutator := &JobMutator{}
+		existingTime := "1735729200"
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+				Labels: map[string]string{
+					LabelKillAfter: existingTime,
+				},
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				TTL:      "1h",
+			},
+		}
+
+		update := func(oldTTL string) context.Context {
+			old := nj.DeepCopy()
+			old.Spec.TTL = oldTTL
+			raw, err := json.Marshal(old)
+			require.NoError(t, err)
+			return admission.NewContextWithRequest(t.Context(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Update,
+					OldObject: runtime.RawExtension{Raw: raw},
+				},
+			})
+		}
+
+		err := mutator.Default(update("1h"), nj)
+		require.NoError(t, err)
+		assert.Equal(t, existingTime, nj.Labels[LabelKillAfter])
+
+		err = mutator.Default(update("2h"), nj)
+		require.NoError(t, err)
+		assert.NotEqual(t, existingTime, nj.Labels[LabelKillAfter])
+	})
+
+	t.Run("ignores kill-after label set in an update", func(t *testing.T) {
+		mutator := &JobMutator{}
+		existingTime := "1735729200"
+		old := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+				Labels: map[string]string{
+					LabelKillAfter: existingTime,
+				},
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				TTL:      "1h",
+			},
+		}
+		update := func(old *Naisjob) context.Context {
+			raw, err := json.Marshal(old)
+			require.NoError(t, err)
+			return admission.NewContextWithRequest(t.Context(), admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: admissionv1.Update,
+					OldObject: runtime.RawExtension{Raw: raw},
+				},
+			})
+		}
+		tampered := func() *Naisjob {
+			nj := old.DeepCopy()
+			nj.Labels[LabelKillAfter] = "4102444800"
+			return nj
+		}
+
+		nj := tampered()
+		err := mutator.Default(update(old), nj)
+		require.NoError(t, err)
+		assert.Equal(t, existingTime, nj.Labels[LabelKillAfter])
+
+		withoutLabel := old.DeepCopy()
+		withoutLabel.Labels = nil
+		nj = tampered()
+		err = mutator.Default(update(withoutLabel), nj)
+		require.NoError(t, err)
+		killAfter, err := strconv.ParseInt(nj.Labels[LabelKillAfter], 10, 64)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, killAfter, time.Now().Add(time.Hour).Unix())
+	})
+
+	t.Run("counts TTL from creation with fixed TTL policy", func(t *testing.T) {
+		mutator := &JobMutator{Config: NewValidatorConfig(WithTTLPolicy(TTLFixedFromCreation))}
+		created := time.Now().Add(-30 * time.Minute).Truncate(time.Second)
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "test-job",
+				Namespace:         "test-ns",
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				TTL:      "1h",
+			},
+		}
+
+		err := mutator.Default(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Equal(t, strconv.FormatInt(created.Add(time.Hour).Unix(), 10), nj.Labels[LabelKillAfter])
+	})
+
+	t.Run("does not set label when TTL is above maximum", func(t *testing.T) {
+		mutator := &JobMutator{Config: NewValidatorConfig(WithMaxTTL(24 * time.Hour))}
+		nj := &Naisjob{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-job",
+				Namespace: "test-ns",
+			},
+			Spec: NaisjobSpec{
+				Image:    "nginx:latest",
+				Schedule: "0 * * * *",
+				TTL:      "48h",
+			},
+		}
+
+		err := mutator.Default(t.Context(), nj)
+		require.NoError(t, err)
+		assert.NotContains(t, nj.Labels, LabelKillAfter)
+	})
+
 	t.Run("handles invalid TTL gracefully", func(t *testing.T) {
 		mutator := &JobMutator{}
 		nj := &Naisjob{
@@ -603,3 +1188,36 @@ func TestJobMutator_Default(t *testing.T) {
 		}
 	})
 }
+
+type countingIndexer struct {
+	fields []string
+}
+
+func (i *countingIndexer) IndexField(_ context.Context, _ client.Object, field string, _ client.IndexerFunc) error {
+	i.fields = append(i.fields, field)
+	return nil
+}
+
+func TestIndexTopicPools(t *testing.T) {
+	indexer := &countingIndexer{}
+	assert.NoError(t, IndexTopicPools(t.Context(), indexer))
+	assert.NoError(t, IndexTopicPools(t.Context(), indexer))
+	assert.Equal(t, []string{TopicPoolIndex}, indexer.fields)
+
+	assert.Equal(t, []string{"nav-dev"}, TopicPool(&kafka_nais_io_v1.Topic{Spec: kafka_nais_io_v1.TopicSpec{Pool: "nav-dev"}}))
+	assert.Nil(t, TopicPool(&Naisjob{}))
+}
+
+func TestValidatorConfig_IndexFields(t *testing.T) {
+	t.Run("without topic ACL warnings", func(t *testing.T) {
+		indexer := &countingIndexer{}
+		assert.NoError(t, NewValidatorConfig().IndexFields(t.Context(), indexer))
+		assert.Empty(t, indexer.fields)
+	})
+
+	t.Run("with topic ACL warnings", func(t *testing.T) {
+		indexer := &countingIndexer{}
+		assert.NoError(t, NewValidatorConfig(WithTopicACLWarnings()).IndexFields(t.Context(), indexer))
+		assert.Equal(t, []string{TopicPoolIndex}, indexer.fields)
+	})
+}
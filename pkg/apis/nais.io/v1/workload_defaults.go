@@ -0,0 +1,132 @@
+package nais_io_v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultsPolicyName is the name of the ConfigMap holding the defaults policy of a namespace,
+	// and the prefix of the ConfigMaps holding the defaults policies of teams.
+	DefaultsPolicyName = "nais-workload-defaults"
+	// DefaultsPolicyKey is the key in a defaults policy ConfigMap that holds the WorkloadDefaults as YAML.
+	DefaultsPolicyKey = "defaults.yaml"
+	// DefaultsPolicyAnnotation records the defaults policies that were applied to a workload, as a comma-separated list of `namespace/name`.
+	DefaultsPolicyAnnotation = "nais.io/defaults-policy"
+	// TeamLabel is the label holding the name of the team that owns a workload.
+	TeamLabel = "team"
+)
+
+// WorkloadDefaults are the values a defaults policy sets on workloads that do not specify them.
+// Fields that do not exist on a kind of workload, e.g. replicas on Naisjobs, are ignored.
+// +kubebuilder:object:generate=false
+type WorkloadDefaults struct {
+	Observability *Observability        `json:"observability,omitempty"`
+	Replicas      *Replicas             `json:"replicas,omitempty"`
+	Resources     *ResourceRequirements `json:"resources,omitempty"`
+}
+
+// WorkloadTeam returns the team that owns a workload, from its TeamLabel, or its namespace if the label is not set.
+func WorkloadTeam(obj metav1.Object) string {
+	if team := obj.GetLabels()[TeamLabel]; len(team) > 0 {
+		return team
+	}
+	return obj.GetNamespace()
+}
+
+// WithDefaultsPolicies enables defaults policies for workloads.
+//
+// The namespace policy is read from the ConfigMap named DefaultsPolicyName in the namespace of the workload.
+// The team policy is read from the ConfigMap named `<DefaultsPolicyName>-<team>` in the given namespace,
+// where the team is taken from the TeamLabel of the workload, or its namespace if the label is not set.
+// Values from the namespace policy take precedence over the team policy.
+//
+// The policies are read directly from the API server, without a cache, which requires permission to get ConfigMaps
+// in all namespaces, but not to list or watch them.
+func WithDefaultsPolicies(namespace string) ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.DefaultsPolicyNamespace = namespace
+	}
+}
+
+// DefaultsPolicy is a parsed defaults policy, along with the ConfigMap it was read from.
+// +kubebuilder:object:generate=false
+type DefaultsPolicy struct {
+	Key      client.ObjectKey
+	Defaults WorkloadDefaults
+}
+
+// DefaultsPolicies returns the defaults policies that apply to a workload, in order of precedence.
+// Policies that do not exist are left out.
+func (cfg ValidatorConfig) DefaultsPolicies(ctx context.Context, reader client.Reader, obj client.Object) ([]DefaultsPolicy, error) {
+	if len(cfg.DefaultsPolicyNamespace) == 0 {
+		return nil, nil
+	}
+
+	team := WorkloadTeam(obj)
+	keys := []client.ObjectKey{
+		{Namespace: obj.GetNamespace(), Name: DefaultsPolicyName},
+		{Namespace: cfg.DefaultsPolicyNamespace, Name: DefaultsPolicyName + "-" + team},
+	}
+
+	var policies []DefaultsPolicy
+	for _, key := range keys {
+		cm := &corev1.ConfigMap{}
+		if err := reader.Get(ctx, key, cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("get defaults policy %s: %w", key, err)
+		}
+
+		policy := DefaultsPolicy{Key: key}
+		if err := yaml.Unmarshal([]byte(cm.Data[DefaultsPolicyKey]), &policy.Defaults); err != nil {
+			return nil, fmt.Errorf("parse defaults policy %s: %w", key, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// applyDefaultsPolicies sets defaults from the policies that apply to the workload, and records them in DefaultsPolicyAnnotation.
+func (m *WorkloadMutator[T]) applyDefaultsPolicies(ctx context.Context, obj T) error {
+	if m.Client == nil || m.Defaults == nil {
+		return nil
+	}
+
+	policies, err := m.Config.DefaultsPolicies(ctx, m.Client, obj)
+	if err != nil {
+		return apierrors.NewInternalError(err)
+	}
+
+	applied := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		if err := m.Defaults(obj, &policy.Defaults); err != nil {
+			return apierrors.NewInternalError(fmt.Errorf("apply defaults policy %s: %w", policy.Key, err))
+		}
+		applied = append(applied, policy.Key.String())
+	}
+
+	annotations := obj.GetAnnotations()
+	if len(applied) == 0 {
+		// Do not keep a record of policies that have since been removed
+		if _, ok := annotations[DefaultsPolicyAnnotation]; ok {
+			delete(annotations, DefaultsPolicyAnnotation)
+			obj.SetAnnotations(annotations)
+		}
+		return nil
+	}
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[DefaultsPolicyAnnotation] = strings.Join(applied, ",")
+	obj.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,405 @@
+package nais_io_v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/nais/liberator/pkg/webhookvalidator"
+	admissionv1 "k8s.io/api/admission/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	LabelKillAfter = "euthanaisa.nais.io/kill-after"
+)
+
+// Workload is the common interface of Applications and Naisjobs used by the shared validator and mutator.
+// +kubebuilder:object:generate=false
+type Workload interface {
+	client.Object
+	AivenInterface
+	GetImage() string
+	GetPostgres() *Postgres
+	GetResources() *ResourceRequirements
+	GetTTL() string
+	GetIngress() []Ingress
+	GetRedirects() []Redirect
+	GetAccessPolicy() *AccessPolicy
+}
+
+var _ Workload = &Naisjob{}
+
+// TTLPolicy decides when the TTL of a workload starts counting.
+type TTLPolicy string
+
+const (
+	// TTLExtendOnUpdate counts the TTL from when it was last changed.
+	TTLExtendOnUpdate TTLPolicy = "ExtendOnUpdate"
+	// TTLFixedFromCreation counts the TTL from when the workload was created.
+	TTLFixedFromCreation TTLPolicy = "FixedFromCreation"
+)
+
+// ValidatorConfig holds cluster specific configuration for the Application and Naisjob validators and mutators.
+// The zero value disables all optional checks.
+// +kubebuilder:object:generate=false
+type ValidatorConfig struct {
+	// KafkaPools is the list of Kafka pools available in the cluster.
+	// If empty, `spec.kafka.pool` is not validated.
+	KafkaPools []string
+	// WarnMissingTopicACL enables a warning for workloads using Kafka that are not granted access to any Topic.
+	WarnMissingTopicACL bool
+	// IngressDomains is the list of domains available for ingresses in the cluster, optionally with wildcards.
+	// If empty, the ingress domain is not validated.
+	IngressDomains []string
+	// MaxCPU and MaxMemory are the largest resource requests and limits a workload can have.
+	// If zero, the resources are not limited.
+	MaxCPU    resource.Quantity
+	MaxMemory resource.Quantity
+	// MaxTTL is the longest TTL a workload can have. If zero, the TTL is not limited.
+	MaxTTL time.Duration
+	// TTLPolicy decides when the TTL starts counting. Defaults to TTLExtendOnUpdate.
+	TTLPolicy TTLPolicy
+	// DefaultsPolicyNamespace is the namespace holding the defaults policies of teams.
+	// If empty, defaults policies are disabled.
+	DefaultsPolicyNamespace string
+	// ClusterName is the name of the cluster, used to check access policy rules against the workloads in it.
+	// It is set by accesspolicy.WithWarnings. If empty, access policies are not checked against other workloads.
+	ClusterName string
+	// AllowInboundWildcards allows inbound access policy rules matching all namespaces.
+	AllowInboundWildcards bool
+	// Checks are run after the built-in checks.
+	Checks []WorkloadCheck
+}
+
+// ValidatorOption configures the validators and mutators set up by SetupWebhookWithManager.
+// +kubebuilder:object:generate=false
+type ValidatorOption func(cfg *ValidatorConfig)
+
+// WithKafkaPools rejects workloads that reference a Kafka pool not in the given list.
+func WithKafkaPools(pools ...string) ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.KafkaPools = pools
+	}
+}
+
+// WithTopicACLWarnings warns about workloads that use Kafka, but are not listed in the ACL of any Topic in the same pool.
+// This requires permission to list Topics in all namespaces.
+func WithTopicACLWarnings() ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.WarnMissingTopicACL = true
+	}
+}
+
+// WithMaxTTL rejects workloads with a TTL longer than the given duration.
+func WithMaxTTL(ttl time.Duration) ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.MaxTTL = ttl
+	}
+}
+
+// WithTTLPolicy decides when the TTL of a workload starts counting.
+func WithTTLPolicy(policy TTLPolicy) ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.TTLPolicy = policy
+	}
+}
+
+// WithWorkloadChecks adds checks that are run for both Applications and Naisjobs, after the built-in checks.
+func WithWorkloadChecks(checks ...WorkloadCheck) ValidatorOption {
+	return func(cfg *ValidatorConfig) {
+		cfg.Checks = append(cfg.Checks, checks...)
+	}
+}
+
+// NewValidatorConfig applies the given options to an empty ValidatorConfig.
+func NewValidatorConfig(opts ...ValidatorOption) ValidatorConfig {
+	cfg := ValidatorConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// IndexFields registers the field indexes used by the enabled checks with the given indexer.
+// Indexes are only registered when needed, as each one makes the cache watch its kind in all namespaces.
+func (cfg ValidatorConfig) IndexFields(ctx context.Context, indexer client.FieldIndexer) error {
+	if cfg.WarnMissingTopicACL {
+		return IndexTopicPools(ctx, indexer)
+	}
+	return nil
+}
+
+// WorkloadRequest holds everything a WorkloadCheck needs to validate a workload.
+// +kubebuilder:object:generate=false
+type WorkloadRequest struct {
+	Client client.Reader
+	// APIReader reads directly from the API server, for point reads of kinds that should not be cached.
+	APIReader client.Reader
+	Logger    logr.Logger
+	Config    ValidatorConfig
+	// Kind is the kind of the workload, e.g. `Application`.
+	Kind string
+
+	Object Workload
+	Spec   any
+	// Old and OldSpec are nil when the workload is created.
+	Old     Workload
+	OldSpec any
+}
+
+// IsCreate returns true if the workload is being created.
+func (r *WorkloadRequest) IsCreate() bool {
+	return r.Old == nil
+}
+
+// WorkloadCheck validates a single aspect of a workload.
+//
+// Return an errors.Aggregate of *field.Error to reject the workload as invalid.
+// The field errors from all checks are collected, and returned together in a single Invalid error.
+// Return an API error, e.g. from apierrors.NewInternalError, to reject the workload immediately with that error.
+// Warnings are only returned to the user if the workload is admitted.
+// +kubebuilder:object:generate=false
+type WorkloadCheck func(ctx context.Context, req *WorkloadRequest) (admission.Warnings, error)
+
+// workloadChecks are the built-in checks, in the order they are run.
+var workloadChecks = []WorkloadCheck{
+	checkName,
+	checkTTL,
+	checkImmutableFields,
+	checkSpecRules,
+	checkResources,
+	checkIngresses,
+	checkRedirects,
+	checkKafka,
+	checkAivenReferences,
+	checkPostgresReference,
+	checkAccessPolicy,
+	checkDeprecations,
+}
+
+// WorkloadValidator runs all workload checks for a specific kind of workload.
+// +kubebuilder:object:generate=false
+type WorkloadValidator[T Workload] struct {
+	Client client.Reader
+	// APIReader reads directly from the API server, e.g. the API reader of a manager. Defaults to Client.
+	APIReader client.Reader
+	Logger    logr.Logger
+	Config    ValidatorConfig
+	Kind      string
+	// Spec returns the spec of the workload.
+	Spec func(T) any
+	// Checks that only apply to this kind of workload. They are run after the shared checks.
+	Checks []WorkloadCheck
+}
+
+func (v *WorkloadValidator[T]) ValidateCreate(ctx context.Context, obj T) (admission.Warnings, error) {
+	return v.validate(ctx, &WorkloadRequest{
+		Object: obj,
+		Spec:   v.Spec(obj),
+	})
+}
+
+func (v *WorkloadValidator[T]) ValidateUpdate(ctx context.Context, old, obj T) (admission.Warnings, error) {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return nil, nil
+	}
+
+	return v.validate(ctx, &WorkloadRequest{
+		Object:  obj,
+		Spec:    v.Spec(obj),
+		Old:     old,
+		OldSpec: v.Spec(old),
+	})
+}
+
+func (v *WorkloadValidator[T]) ValidateDelete(ctx context.Context, obj T) (admission.Warnings, error) {
+	return nil, nil
+}
+
+func (v *WorkloadValidator[T]) validate(ctx context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	req.Client = v.Client
+	req.APIReader = v.APIReader
+	if req.APIReader == nil {
+		req.APIReader = v.Client
+	}
+	req.Logger = v.Logger
+	req.Config = v.Config
+	req.Kind = v.Kind
+
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+	for _, check := range slices.Concat(workloadChecks, v.Checks, v.Config.Checks) {
+		w, err := check(ctx, req)
+		if agg, ok := err.(errors.Aggregate); ok {
+			allErrs = append(allErrs, fromAggregate(agg)...)
+		} else if err != nil {
+			return nil, err
+		}
+		warnings = append(warnings, w...)
+	}
+
+	if len(allErrs) > 0 {
+		return nil, apierrors.NewInvalid(
+			schema.GroupKind{Group: GroupVersion.Group, Kind: v.Kind},
+			req.Object.GetName(),
+			allErrs,
+		)
+	}
+	return warnings, nil
+}
+
+// fromAggregate converts the errors returned by a check to field errors.
+// Checks are expected to only aggregate field errors; anything else is reported as an internal error.
+func fromAggregate(agg errors.Aggregate) field.ErrorList {
+	errs := agg.Errors()
+	list := make(field.ErrorList, len(errs))
+	for i, err := range errs {
+		switch err := err.(type) {
+		case *field.Error:
+			list[i] = err
+		default:
+			list[i] = field.InternalError(nil, err)
+		}
+	}
+	return list
+}
+
+func checkName(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	name := req.Object.GetName()
+	if req.IsCreate() && len(name) > validation.LabelValueMaxLength {
+		path := field.NewPath("metadata", "name")
+		return nil, field.ErrorList{field.Invalid(path, name, fmt.Sprintf("%s name length must be no more than %d characters", req.Kind, validation.LabelValueMaxLength))}.ToAggregate()
+	}
+	return nil, nil
+}
+
+func checkTTL(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	ttl := req.Object.GetTTL()
+	if ttl == "" {
+		return nil, nil
+	}
+
+	path := field.NewPath("spec", "ttl")
+	d, err := time.ParseDuration(ttl)
+	if err != nil {
+		if req.IsCreate() {
+			return nil, field.ErrorList{field.Invalid(path, ttl, "TTL is not a valid duration. Example of valid duration is '12h'")}.ToAggregate()
+		}
+		return nil, nil
+	}
+
+	// A lower maximum only applies to workloads when their TTL is changed
+	changed := req.IsCreate() || req.Old.GetTTL() != ttl
+	if maxTTL := req.Config.MaxTTL; maxTTL > 0 && d > maxTTL && changed {
+		return nil, field.ErrorList{field.Invalid(path, ttl, fmt.Sprintf("must be no longer than %s", maxTTL))}.ToAggregate()
+	}
+
+	killAfter, err := strconv.ParseInt(req.Object.GetLabels()[LabelKillAfter], 10, 64)
+	if err != nil {
+		return nil, nil
+	}
+	deletion := time.Unix(killAfter, 0).UTC().Format(time.RFC3339)
+	return admission.Warnings{fmt.Sprintf("%s %s will be deleted at %s, when its TTL of %s expires", req.Kind, req.Object.GetName(), deletion, ttl)}, nil
+}
+
+func checkImmutableFields(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	if req.IsCreate() {
+		return nil, nil
+	}
+	return nil, webhookvalidator.NaisCompare(req.Spec, req.OldSpec, field.NewPath("spec"))
+}
+
+func checkSpecRules(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	return nil, webhookvalidator.Validate(req.Spec, field.NewPath("spec"))
+}
+
+func checkDeprecations(_ context.Context, req *WorkloadRequest) (admission.Warnings, error) {
+	return DeprecationWarnings(req.Spec, field.NewPath("spec")), nil
+}
+
+// WorkloadMutator sets defaults that are common to all workloads.
+// +kubebuilder:object:generate=false
+type WorkloadMutator[T Workload] struct {
+	// Client reads defaults policies. Use a reader without a cache, e.g. the API reader of a manager,
+	// as a cache would watch every ConfigMap in the cluster.
+	Client client.Reader
+	Config ValidatorConfig
+	// Defaults sets the values from a defaults policy on the fields of the workload that are not set.
+	Defaults func(obj T, defaults *WorkloadDefaults) error
+}
+
+// Default implements webhook.CustomDefaulter - applies defaults policies, and sets kill-after label if TTL is specified.
+func (m *WorkloadMutator[T]) Default(ctx context.Context, obj T) error {
+	if err := m.applyDefaultsPolicies(ctx, obj); err != nil {
+		return err
+	}
+	m.setKillAfter(ctx, obj)
+	return nil
+}
+
+// setKillAfter sets the kill-after label from the TTL.
+//
+// The label of the old workload is kept when the TTL is unchanged, so that updates do not extend the lifetime of the workload.
+// Otherwise, the TTL counts from now, or from the creation of the workload, depending on the TTL policy.
+// The label is never taken from the incoming workload, as that would let users choose their own deletion time.
+func (m *WorkloadMutator[T]) setKillAfter(ctx context.Context, obj T) {
+	if obj.GetTTL() == "" {
+		return
+	}
+
+	d, err := time.ParseDuration(obj.GetTTL())
+	if err != nil || (m.Config.MaxTTL > 0 && d > m.Config.MaxTTL) {
+		return // Validation webhook will catch this
+	}
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+
+	if oldTTL, oldKillAfter, ok := oldWorkloadTTL(ctx); ok && oldTTL == obj.GetTTL() && len(oldKillAfter) > 0 {
+		labels[LabelKillAfter] = oldKillAfter
+		obj.SetLabels(labels)
+		return
+	}
+
+	start := time.Now()
+	if created := obj.GetCreationTimestamp(); m.Config.TTLPolicy == TTLFixedFromCreation && !created.IsZero() {
+		start = created.Time
+	}
+	labels[LabelKillAfter] = strconv.FormatInt(start.Add(d).Unix(), 10)
+	obj.SetLabels(labels)
+}
+
+// oldWorkloadTTL returns the TTL and kill-after label of the workload before an update, if the context holds an update request.
+func oldWorkloadTTL(ctx context.Context) (ttl, killAfter string, ok bool) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil || req.Operation != admissionv1.Update {
+		return "", "", false
+	}
+
+	old := struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+		Spec struct {
+			TTL string `json:"ttl"`
+		} `json:"spec"`
+	}{}
+	if err := json.Unmarshal(req.OldObject.Raw, &old); err != nil {
+		return "", "", false
+	}
+	return old.Spec.TTL, old.Metadata.Labels[LabelKillAfter], true
+}
@@ -0,0 +1,101 @@
+package nais_io_v1_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	nais_io_v1alpha1 "github.com/nais/liberator/pkg/apis/nais.io/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestWorkloadValidator_Checks(t *testing.T) {
+	// A check that is registered once and applies to both Applications and Naisjobs
+	check := func(_ context.Context, req *nais_io_v1.WorkloadRequest) (admission.Warnings, error) {
+		if req.Object.GetTTL() == "" {
+			return admission.Warnings{req.Kind + " has no TTL"}, nil
+		}
+		if !req.IsCreate() && req.Old.GetTTL() != req.Object.GetTTL() {
+			return nil, field.ErrorList{field.Forbidden(field.NewPath("spec", "ttl"), "may not be changed")}.ToAggregate()
+		}
+		return nil, nil
+	}
+	config := nais_io_v1.NewValidatorConfig(nais_io_v1.WithWorkloadChecks(check))
+	client := fake.NewClientBuilder().Build()
+	meta := metav1.ObjectMeta{Name: "test", Namespace: "test-ns"}
+
+	app := &nais_io_v1alpha1.Application{ObjectMeta: meta}
+	appValidator := &nais_io_v1.WorkloadValidator[*nais_io_v1alpha1.Application]{
+		Client: client,
+		Config: config,
+		Kind:   "Application",
+		Spec:   func(a *nais_io_v1alpha1.Application) any { return a.Spec },
+	}
+
+	nj := &nais_io_v1.Naisjob{ObjectMeta: meta, Spec: nais_io_v1.NaisjobSpec{Schedule: "0 * * * *"}}
+	jobValidator := &nais_io_v1.WorkloadValidator[*nais_io_v1.Naisjob]{
+		Client: client,
+		Config: config,
+		Kind:   "Naisjob",
+		Spec:   func(nj *nais_io_v1.Naisjob) any { return nj.Spec },
+	}
+
+	t.Run("warnings", func(t *testing.T) {
+		warnings, err := appValidator.ValidateCreate(t.Context(), app)
+		require.NoError(t, err)
+		assert.Equal(t, admission.Warnings{"Application has no TTL"}, warnings)
+
+		warnings, err = jobValidator.ValidateCreate(t.Context(), nj)
+		require.NoError(t, err)
+		assert.Equal(t, admission.Warnings{"Naisjob has no TTL"}, warnings)
+	})
+
+	t.Run("field errors", func(t *testing.T) {
+		oldApp, newApp := app.DeepCopy(), app.DeepCopy()
+		oldApp.Spec.TTL, newApp.Spec.TTL = "1h", "2h"
+		_, err := appValidator.ValidateUpdate(t.Context(), oldApp, newApp)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), `Application.nais.io "test" is invalid: spec.ttl: Forbidden`)
+
+		oldJob, newJob := nj.DeepCopy(), nj.DeepCopy()
+		oldJob.Spec.TTL, newJob.Spec.TTL = "1h", "2h"
+		_, err = jobValidator.ValidateUpdate(t.Context(), oldJob, newJob)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), `Naisjob.nais.io "test" is invalid: spec.ttl: Forbidden`)
+	})
+
+	t.Run("deleted workloads are not validated", func(t *testing.T) {
+		oldJob, newJob := nj.DeepCopy(), nj.DeepCopy()
+		newJob.Spec.TTL = "2h"
+		newJob.DeletionTimestamp = new(metav1.Now())
+		warnings, err := jobValidator.ValidateUpdate(t.Context(), oldJob, newJob)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("aggregated errors that are not field errors", func(t *testing.T) {
+		check := func(context.Context, *nais_io_v1.WorkloadRequest) (admission.Warnings, error) {
+			return nil, utilerrors.NewAggregate([]error{errors.New("x")})
+		}
+		validator := &nais_io_v1.WorkloadValidator[*nais_io_v1.Naisjob]{
+			Client: client,
+			Config: nais_io_v1.NewValidatorConfig(nais_io_v1.WithWorkloadChecks(check)),
+			Kind:   "Naisjob",
+			Spec:   func(nj *nais_io_v1.Naisjob) any { return nj.Spec },
+		}
+		_, err := validator.ValidateCreate(t.Context(), nj)
+		require.Error(t, err)
+		assert.True(t, apierrors.IsInvalid(err))
+		assert.Contains(t, err.Error(), "Internal error: x")
+	})
+}
@@ -33,6 +33,28 @@ func (app *Application) ApplyDefaults() error {
 	return nil
 }
 
+// ApplyDefaultsPolicy sets values from a defaults policy where they are missing from an Application spec.
+func (app *Application) ApplyDefaultsPolicy(defaults *nais_io_v1.WorkloadDefaults) error {
+	replicasIsZero := app.replicasDefined() && app.replicasIsZero()
+	err := mergo.Merge(app, &Application{
+		Spec: ApplicationSpec{
+			Observability: defaults.Observability,
+			Replicas:      defaults.Replicas,
+			Resources:     defaults.Resources,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if replicasIsZero {
+		app.Spec.Replicas.Min = new(0)
+		app.Spec.Replicas.Max = new(0)
+	}
+
+	return nil
+}
+
 func (app *Application) replicasDefined() bool {
 	if app.Spec.Replicas != nil && app.Spec.Replicas.Min != nil && app.Spec.Replicas.Max != nil {
 		return true
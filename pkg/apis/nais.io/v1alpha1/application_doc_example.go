@@ -49,6 +49,13 @@ func ExampleApplicationForDocumentation() *Application {
 								Namespace:   "q3",
 							},
 						},
+						{
+							AccessPolicyRule: nais_io_v1.AccessPolicyRule{
+								Application: "*",
+								Namespace:   "*",
+								Team:        "myteam",
+							},
+						},
 						{
 							AccessPolicyRule: nais_io_v1.AccessPolicyRule{
 								Application: "app4",
@@ -94,6 +101,11 @@ func ExampleApplicationForDocumentation() *Application {
 							Application: "*",
 							Namespace:   "q3",
 						},
+						{
+							Application: "*",
+							Namespace:   "*",
+							Team:        "myteam",
+						},
 					},
 					External: []nais_io_v1.AccessPolicyExternalRule{
 						{
@@ -110,6 +122,21 @@ func ExampleApplicationForDocumentation() *Application {
 						{
 							IPv4: "1.2.3.4",
 						},
+						{
+							IPv6: "2001:db8::1",
+						},
+						{
+							CIDR: "10.0.0.0/24",
+						},
+						{
+							Host: "*.dns.example.com",
+							Ports: []nais_io_v1.AccessPolicyPortRule{
+								{
+									Port:     53,
+									Protocol: "UDP",
+								},
+							},
+						},
 						{
 							Host: "non-http-service.example.com",
 							Ports: []nais_io_v1.AccessPolicyPortRule{
@@ -322,6 +349,7 @@ func ExampleApplicationForDocumentation() *Application {
 			Image: "navikt/testapp:69.0.0",
 			Ingresses: []nais_io_v1.Ingress{
 				"https://myapplication.nav.no",
+				"https://myapplication-old.nav.no",
 			},
 			Kafka: &nais_io_v1.Kafka{
 				Pool:    "nav-dev",
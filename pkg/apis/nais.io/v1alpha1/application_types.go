@@ -172,7 +172,8 @@ type ApplicationSpec struct {
 	// An HTTP GET will be issued to this endpoint at least once before the pod is terminated.
 	// This feature is deprecated and will be removed in the next major version (nais.io/v1).
 	// +nais:doc:Link="https://doc.nais.io/workloads/explanations/good-practices/#handles-termination-gracefully"
-	PreStopHookPath string `json:"preStopHookPath,omitempty"`
+	// +nais:doc:Deprecated=true
+	PreStopHookPath string `json:"preStopHookPath,omitempty" nais:"deprecated"`
 
 	// Prometheus is used to [scrape metrics from the pod](https://doc.nais.io/observability/metrics/).
 	// Use this configuration to override the default values.
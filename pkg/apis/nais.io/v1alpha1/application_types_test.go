@@ -8,6 +8,7 @@ import (
 	"github.com/mitchellh/hashstructure"
 	nais_io_v1alpha1 "github.com/nais/liberator/pkg/apis/nais.io/v1alpha1"
 	"github.com/nais/liberator/pkg/hash"
+	"github.com/nais/liberator/pkg/webhookvalidator"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -76,3 +77,7 @@ func TestNewCRD(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equalf(t, applicationHash, hash, "Your Application default value changes will trigger a FULL REDEPLOY of ALL APPLICATIONS in ALL NAMESPACES across ALL CLUSTERS. If this is what you really want, change the `applicationHash` constant in this test file to `%s`.", hash)
 }
+
+func TestApplicationSpec_Lint(t *testing.T) {
+	assert.NoError(t, webhookvalidator.Lint(nais_io_v1alpha1.ApplicationSpec{}))
+}
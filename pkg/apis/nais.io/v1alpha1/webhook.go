@@ -2,45 +2,54 @@ package nais_io_v1alpha1
 
 import (
 	"context"
-	"fmt"
-	"strconv"
-	"time"
 
 	"github.com/go-logr/logr"
-	aiven_io_v1alpha1 "github.com/nais/liberator/pkg/apis/aiven.io/v1alpha1"
-	aiven_nais_io_v1 "github.com/nais/liberator/pkg/apis/aiven.nais.io/v1"
-	"github.com/nais/liberator/pkg/webhookvalidator"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
-	"k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/validation"
-	"k8s.io/apimachinery/pkg/util/validation/field"
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 const (
-	LabelKillAfter = "euthanaisa.nais.io/kill-after"
+	LabelKillAfter = nais_io_v1.LabelKillAfter
 )
 
+var _ nais_io_v1.Workload = &Application{}
+
 // +kubebuilder:object:generate=false
 type ApplicationValidator struct {
 	client.Client
-	logger logr.Logger
+	apiReader client.Reader
+	logger    logr.Logger
+	config    nais_io_v1.ValidatorConfig
 }
 
 // +kubebuilder:object:generate=false
-type ApplicationMutator struct{}
+type ApplicationMutator = nais_io_v1.WorkloadMutator[*Application]
+
+func SetupWebhookWithManager(mgr ctrl.Manager, opts ...nais_io_v1.ValidatorOption) error {
+	err := mgr.GetFieldIndexer().IndexField(context.Background(), &Application{}, IngressHostIndex, IngressHosts)
+	if err != nil {
+		return err
+	}
+
+	config := nais_io_v1.NewValidatorConfig(opts...)
+	if err := config.IndexFields(context.Background(), mgr.GetFieldIndexer()); err != nil {
+		return err
+	}
 
-func SetupWebhookWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewWebhookManagedBy(mgr, &Application{}).
 		WithValidator(&ApplicationValidator{
-			Client: mgr.GetClient(),
-			logger: mgr.GetLogger().WithName("application-validator"),
+			Client:    mgr.GetClient(),
+			apiReader: mgr.GetAPIReader(),
+			logger:    mgr.GetLogger().WithName("application-validator"),
+			config:    config,
+		}).
+		WithDefaulter(&ApplicationMutator{
+			Client:   mgr.GetAPIReader(),
+			Config:   config,
+			Defaults: (*Application).ApplyDefaultsPolicy,
 		}).
-		WithDefaulter(&ApplicationMutator{}).
 		Complete()
 }
 
@@ -49,131 +58,32 @@ func SetupWebhookWithManager(mgr ctrl.Manager) error {
 // DISABLE: +kubebuilder:webhook:verbs=create;update,path=/validate-nais-io-v1alpha1-application,mutating=false,failurePolicy=fail,groups=nais.io,resources=applications,versions=v1alpha1,name=validation.applications.nais.io
 
 func (v *ApplicationValidator) ValidateCreate(ctx context.Context, a *Application) (warnings admission.Warnings, err error) {
-	if len(a.GetName()) > validation.LabelValueMaxLength {
-		return nil, apierrors.NewBadRequest(fmt.Sprintf("Application name length must be no more than %d characters", validation.LabelValueMaxLength))
-	}
-
-	if a.Spec.TTL != "" {
-		if _, err := time.ParseDuration(a.Spec.TTL); err != nil {
-			return nil, apierrors.NewBadRequest(fmt.Sprintf("TTL is not a valid duration: %q. Example of valid duration is '12h'", a.Spec.TTL))
-		}
-	}
-
-	if err := v.checkAivenReferences(ctx, a); err != nil {
-		return nil, err
-	}
-
-	if err := v.checkPostgresReference(ctx, a); err != nil {
-		return nil, err
-	}
-
-	return nil, nil
+	return v.workloadValidator().ValidateCreate(ctx, a)
 }
 
 func (v *ApplicationValidator) ValidateUpdate(ctx context.Context, oldA *Application, a *Application) (warnings admission.Warnings, err error) {
-	if !a.GetDeletionTimestamp().IsZero() {
-		return nil, nil
-	}
-
-	if err := webhookvalidator.NaisCompare(a.Spec, oldA.Spec, field.NewPath("spec")); err != nil {
-		if allErrs, ok := err.(errors.Aggregate); ok {
-			return nil, apierrors.NewInvalid(
-				schema.GroupKind{Group: GroupVersion.Group, Kind: "Application"},
-				a.Name,
-				fromAggregate(allErrs),
-			)
-		}
-
-		return nil, err
-	}
-
-	if err := v.checkAivenReferences(ctx, a); err != nil {
-		return nil, err
-	}
-
-	if err := v.checkPostgresReference(ctx, a); err != nil {
-		return nil, err
-	}
-
-	return nil, nil
+	return v.workloadValidator().ValidateUpdate(ctx, oldA, a)
 }
 
 func (v *ApplicationValidator) ValidateDelete(ctx context.Context, obj *Application) (warnings admission.Warnings, err error) {
-	return nil, nil
-}
-
-func (v *ApplicationValidator) checkAivenReferences(ctx context.Context, app *Application) error {
-	if app.Spec.OpenSearch != nil && app.Spec.OpenSearch.Instance != "" {
-		fullyQualifiedName := aiven_nais_io_v1.OpenSearchFullyQualifiedName(app.Spec.OpenSearch.Instance, app.Namespace)
-		opensearch := &aiven_io_v1alpha1.OpenSearch{}
-		if err := v.Get(ctx, client.ObjectKey{Name: fullyQualifiedName, Namespace: app.Namespace}, opensearch); err != nil {
-			if apierrors.IsNotFound(err) {
-				return apierrors.NewBadRequest(fmt.Sprintf("OpenSearch '%s' does not exist. Create the OpenSearch instance first.", app.Spec.OpenSearch.Instance))
-			}
-			return apierrors.NewInternalError(fmt.Errorf("could not validate OpenSearch reference: %w", err))
-		}
-	}
-
-	for _, valkey := range app.Spec.Valkey {
-		fullyQualifiedName := aiven_nais_io_v1.ValkeyFullyQualifiedName(valkey.Instance, app.Namespace)
-		valkeyObj := &aiven_io_v1alpha1.Valkey{}
-		if err := v.Get(ctx, client.ObjectKey{Name: fullyQualifiedName, Namespace: app.Namespace}, valkeyObj); err != nil {
-			if apierrors.IsNotFound(err) {
-				return apierrors.NewBadRequest(fmt.Sprintf("Valkey '%s' does not exist. Create the Valkey instance first.", valkey.Instance))
-			}
-			return apierrors.NewInternalError(fmt.Errorf("could not validate Valkey reference: %w", err))
-		}
-	}
-	return nil
-}
-
-func (v *ApplicationValidator) checkPostgresReference(ctx context.Context, app *Application) error {
-	if app.Spec.Postgres != nil && app.Spec.Postgres.ClusterName != "" {
-		pgMetaData := &metav1.PartialObjectMetadata{
-			TypeMeta: metav1.TypeMeta{
-				APIVersion: "data.nais.io/v1",
-				Kind:       "Postgres",
-			},
-		}
-		if err := v.Get(ctx, client.ObjectKey{Name: app.Spec.Postgres.ClusterName, Namespace: app.GetNamespace()}, pgMetaData); err != nil {
-			if apierrors.IsNotFound(err) {
-				v.logger.Info("Rejecting application because the Postgres cluster does not exist",
-					"application", app.GetName(),
-					"namespace", app.GetNamespace(),
-					"postgresCluster", app.Spec.Postgres.ClusterName,
-				)
-				return apierrors.NewBadRequest(fmt.Sprintf("Postgres '%s' does not exist. Create the Postgres cluster first.", app.Spec.Postgres.ClusterName))
-			}
-			v.logger.Error(err, "internal error when validating Postgres reference")
-			return apierrors.NewInternalError(fmt.Errorf("could not validate Postgres reference: %w", err))
-		}
-	}
-	return nil
-}
-
-func fromAggregate(agg errors.Aggregate) field.ErrorList {
-	errs := agg.Errors()
-	list := make(field.ErrorList, len(errs))
-	for i := range errs {
-		list[i] = errs[i].(*field.Error)
-	}
-	return list
+	return v.workloadValidator().ValidateDelete(ctx, obj)
 }
 
-// Default implements webhook.CustomDefaulter - sets kill-after label if TTL is specified
-func (m *ApplicationMutator) Default(ctx context.Context, app *Application) error {
-	if app.Spec.TTL == "" {
-		return nil
-	}
-
-	d, err := time.ParseDuration(app.Spec.TTL)
-	if err != nil {
-		return nil // Validation webhook will catch this
-	}
-
-	if app.Labels == nil {
-		app.Labels = make(map[string]string)
+func (v *ApplicationValidator) workloadValidator() *nais_io_v1.WorkloadValidator[*Application] {
+	return &nais_io_v1.WorkloadValidator[*Application]{
+		Client:    v.Client,
+		APIReader: v.apiReader,
+		Logger:    v.logger,
+		Config:    v.config,
+		Kind:      "Application",
+		Spec: func(a *Application) any {
+			return a.Spec
+		},
+		Checks: []nais_io_v1.WorkloadCheck{
+			checkIngressConflicts,
+			checkReplicas,
+			checkProbes,
+			checkTokenClients,
+		},
 	}
-	app.Labels[LabelKillAfter] = strconv.FormatInt(time.Now().Add(d).Unix(), 10)
-	return nil
 }
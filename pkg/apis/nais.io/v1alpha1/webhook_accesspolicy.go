@@ -0,0 +1,21 @@
+package nais_io_v1alpha1
+
+import (
+	"context"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func checkTokenClients(_ context.Context, req *nais_io_v1.WorkloadRequest) (admission.Warnings, error) {
+	app := req.Object.(*Application)
+	var clients []string
+	if azure := app.Spec.Azure; azure != nil && azure.Application != nil && azure.Application.Enabled {
+		clients = append(clients, "spec.azure.application")
+	}
+	if tokenx := app.Spec.TokenX; tokenx != nil && tokenx.Enabled {
+		clients = append(clients, "spec.tokenx")
+	}
+	return nil, nais_io_v1.ValidateTokenClientRules(app.Spec.AccessPolicy, clients, field.NewPath("spec", "accessPolicy")).ToAggregate()
+}
@@ -0,0 +1,106 @@
+package nais_io_v1alpha1
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// IngressHostIndex is the name of the field index on the hosts of an Application's ingresses.
+const IngressHostIndex = "spec.ingresses.host"
+
+// IngressHosts extracts the unique hosts from the ingresses of an Application, for use with IngressHostIndex.
+func IngressHosts(obj client.Object) []string {
+	app, ok := obj.(*Application)
+	if !ok {
+		return nil
+	}
+
+	var hosts []string
+	for _, ingress := range app.Spec.Ingresses {
+		parsed, err := nais_io_v1.ParseIngress(string(ingress))
+		if err != nil || slices.Contains(hosts, parsed.Host) {
+			continue
+		}
+		hosts = append(hosts, parsed.Host)
+	}
+	return hosts
+}
+
+// checkIngressConflicts rejects ingresses whose host and path are already used by another Application.
+//
+// Only ingresses are considered. Redirects are served from the application's own ingresses,
+// and a redirect target may point anywhere, so neither can cause a conflict on its own.
+func checkIngressConflicts(ctx context.Context, req *nais_io_v1.WorkloadRequest) (admission.Warnings, error) {
+	app := req.Object.(*Application)
+	path := field.NewPath("spec", "ingresses")
+
+	owners := map[string][]*Application{}
+	var allErrs field.ErrorList
+	for i, ingress := range app.Spec.Ingresses {
+		parsed, err := nais_io_v1.ParseIngress(string(ingress))
+		if err != nil {
+			// Already reported by the shared ingress check
+			continue
+		}
+
+		if _, ok := owners[parsed.Host]; !ok {
+			if err := indexIngressOwners(ctx, req.Client, parsed.Host, owners); err != nil {
+				return nil, apierrors.NewInternalError(fmt.Errorf("could not check for ingress conflicts: %w", err))
+			}
+		}
+
+		var others []string
+		for _, owner := range owners[parsed.String()] {
+			if owner.Namespace != app.Namespace || owner.Name != app.Name {
+				others = append(others, owner.Namespace+"/"+owner.Name)
+			}
+		}
+		switch len(others) {
+		case 0:
+			continue
+		case 1:
+			allErrs = append(allErrs, field.Invalid(path.Index(i), ingress, fmt.Sprintf("ingress is already in use by application %s", others[0])))
+		default:
+			slices.Sort(others)
+			allErrs = append(allErrs, field.Invalid(path.Index(i), ingress, fmt.Sprintf("ingress is already in use by applications %s", strings.Join(others, ", "))))
+		}
+	}
+
+	return nil, allErrs.ToAggregate()
+}
+
+// indexIngressOwners looks up all Applications with ingresses on the given host, and records the owners of each host and path.
+// The host itself is always recorded, so that it is only looked up once.
+func indexIngressOwners(ctx context.Context, reader client.Reader, host string, owners map[string][]*Application) error {
+	owners[host] = nil
+
+	apps := &ApplicationList{}
+	if err := reader.List(ctx, apps, client.MatchingFields{IngressHostIndex: host}); err != nil {
+		return err
+	}
+
+	for i := range apps.Items {
+		other := &apps.Items[i]
+		if !other.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		for _, ingress := range other.Spec.Ingresses {
+			parsed, err := nais_io_v1.ParseIngress(string(ingress))
+			if err != nil || parsed.Host != host {
+				continue
+			}
+			if !slices.Contains(owners[parsed.String()], other) {
+				owners[parsed.String()] = append(owners[parsed.String()], other)
+			}
+		}
+	}
+	return nil
+}
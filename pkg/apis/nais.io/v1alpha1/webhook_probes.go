@@ -0,0 +1,37 @@
+package nais_io_v1alpha1
+
+import (
+	"context"
+	"strconv"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func checkProbes(_ context.Context, req *nais_io_v1.WorkloadRequest) (admission.Warnings, error) {
+	app := req.Object.(*Application)
+	probes := nais_io_v1.Probes{
+		Liveness:  app.Spec.Liveness,
+		Readiness: app.Spec.Readiness,
+		Startup:   app.Spec.Startup,
+	}
+	warnings, allErrs := nais_io_v1.ValidateProbes(probes, app.declaredPorts(), field.NewPath("spec"))
+	return warnings, allErrs.ToAggregate()
+}
+
+// declaredPorts returns the container ports of the application, i.e. the application port and the metrics port.
+func (app *Application) declaredPorts() []int {
+	port := app.Spec.Port
+	if port == 0 {
+		port = DefaultAppPort
+	}
+	ports := []int{port}
+
+	if app.Spec.Prometheus != nil {
+		if metrics, err := strconv.Atoi(app.Spec.Prometheus.Port); err == nil && metrics != port {
+			ports = append(ports, metrics)
+		}
+	}
+	return ports
+}
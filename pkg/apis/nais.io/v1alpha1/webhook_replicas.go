@@ -0,0 +1,27 @@
+package nais_io_v1alpha1
+
+import (
+	"context"
+
+	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func checkReplicas(ctx context.Context, req *nais_io_v1.WorkloadRequest) (admission.Warnings, error) {
+	app := req.Object.(*Application)
+	path := field.NewPath("spec", "replicas")
+
+	warnings, allErrs := nais_io_v1.ValidateReplicas(app.Spec.Replicas, path)
+
+	if replicas := app.Spec.Replicas; replicas != nil && replicas.ScalingStrategy != nil {
+		topicPath := path.Child("scalingStrategy", "kafka", "topic")
+		errs, err := nais_io_v1.ValidateKafkaScalingTopic(ctx, req.APIReader, replicas.ScalingStrategy.Kafka, app.Spec.Kafka, app.Namespace, app.Name, topicPath)
+		if err != nil {
+			return nil, err
+		}
+		allErrs = append(allErrs, errs...)
+	}
+
+	return warnings, allErrs.ToAggregate()
+}
@@ -1,16 +1,20 @@
 package nais_io_v1alpha1
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	aiven_io_v1alpha1 "github.com/nais/liberator/pkg/apis/aiven.io/v1alpha1"
 	aiven_nais_io_v1 "github.com/nais/liberator/pkg/apis/aiven.nais.io/v1"
+	kafka_nais_io_v1 "github.com/nais/liberator/pkg/apis/kafka.nais.io/v1"
 	nais_io_v1 "github.com/nais/liberator/pkg/apis/nais.io/v1"
 	data_nais_io_v1 "github.com/nais/pgrator/pkg/api/datav1"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -24,10 +28,15 @@ func fakeKubeClient(objs ...client.Object) client.Client {
 	// Add necessary schemes for the test
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = aiven_io_v1alpha1.AddToScheme(scheme)
+	_ = kafka_nais_io_v1.AddToScheme(scheme)
 	_ = data_nais_io_v1.AddToScheme(scheme)
 	_ = AddToScheme(scheme)
 
-	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+	return fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(objs...).
+		WithIndex(&Application{}, IngressHostIndex, IngressHosts).
+		WithIndex(&kafka_nais_io_v1.Topic{}, nais_io_v1.TopicPoolIndex, nais_io_v1.TopicPool).
+		Build()
 }
 
 func TestApplicationValidator_ValidateCreate(t *testing.T) {
@@ -48,6 +57,277 @@ func TestApplicationValidator_ValidateCreate(t *testing.T) {
 		assert.Empty(t, warnings)
 	})
 
+	t.Run("deprecated fields give warnings", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image:           "nginx:latest",
+				PreStopHookPath: "/internal/stop",
+				Replicas: &nais_io_v1.Replicas{
+					CpuThresholdPercentage: 50,
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{
+			"spec.preStopHookPath is deprecated: use spec.preStopHook.http.path instead",
+			"spec.replicas.cpuThresholdPercentage is deprecated: use spec.replicas.scalingStrategy.cpu.thresholdPercentage instead",
+		}, []string(warnings))
+	})
+
+	t.Run("valkey owned by same team with valid access", func(t *testing.T) {
+		valkey := &aiven_io_v1alpha1.Valkey{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      aiven_nais_io_v1.ValkeyFullyQualifiedName("my-valkey", "test-ns"),
+				Namespace: "test-ns",
+			},
+			Spec: aiven_io_v1alpha1.ValkeySpec{
+				ServiceCommonSpec: aiven_io_v1alpha1.ServiceCommonSpec{
+					Tags: map[string]string{"team": "test-ns"},
+				},
+			},
+		}
+
+		validator := &ApplicationValidator{Client: fakeKubeClient(valkey)}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image:  "nginx:latest",
+				Valkey: []nais_io_v1.Valkey{{Instance: "my-valkey", Access: "write"}},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("ingress on unavailable domain", func(t *testing.T) {
+		validator := &ApplicationValidator{
+			Client: fakeKubeClient(),
+			config: nais_io_v1.NewValidatorConfig(nais_io_v1.WithIngressDomains("*.intern.nav.no")),
+		}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				Ingresses: []nais_io_v1.Ingress{
+					"https://test-app.intern.nav.no",
+					"https://test-app.example.com",
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.ingresses[1]")
+		assert.NotContains(t, err.Error(), "spec.ingresses[0]")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("malformed redirect is reported once", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image:     "nginx:latest",
+				Ingresses: []nais_io_v1.Ingress{"https://test-app.intern.nav.no"},
+				Redirects: []nais_io_v1.Redirect{{From: "test-app.intern.nav.no", To: "https://test-app.nav.no"}},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), `spec.redirects[0].from: Invalid value: "test-app.intern.nav.no": not a valid absolute URL`)
+		assert.Equal(t, 1, strings.Count(err.Error(), "spec.redirects[0].from"))
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("ingress already used by another application", func(t *testing.T) {
+		other := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-app",
+				Namespace: "other-ns",
+			},
+			Spec: ApplicationSpec{
+				Ingresses: []nais_io_v1.Ingress{"https://shared.intern.nav.no/api/"},
+			},
+		}
+		validator := &ApplicationValidator{Client: fakeKubeClient(other)}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				Ingresses: []nais_io_v1.Ingress{
+					"https://shared.intern.nav.no",
+					"https://SHARED.intern.nav.no/api",
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.ingresses[1]")
+		assert.Contains(t, err.Error(), "already in use by application other-ns/other-app")
+		assert.NotContains(t, err.Error(), "spec.ingresses[0]")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("ingress used by this and other applications", func(t *testing.T) {
+		owner := func(namespace, name string) *Application {
+			return &Application{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec: ApplicationSpec{
+					Ingresses: []nais_io_v1.Ingress{"https://shared.intern.nav.no/api"},
+				},
+			}
+		}
+
+		// The other applications are listed both before and after the application itself
+		for _, namespaces := range [][]string{{"aaa-ns", "bbb-ns"}, {"yyy-ns", "zzz-ns"}} {
+			t.Run(namespaces[0], func(t *testing.T) {
+				app := owner("test-ns", "test-app")
+				app.Spec.Image = "nginx:latest"
+				validator := &ApplicationValidator{Client: fakeKubeClient(
+					owner(namespaces[1], "other-app"),
+					app.DeepCopy(),
+					owner(namespaces[0], "other-app"),
+				)}
+
+				warnings, err := validator.ValidateUpdate(t.Context(), app, app)
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), fmt.Sprintf("spec.ingresses[0]: Invalid value: \"https://shared.intern.nav.no/api\": ingress is already in use by applications %s/other-app, %s/other-app", namespaces[0], namespaces[1]))
+				assert.Empty(t, warnings)
+			})
+		}
+	})
+
+	t.Run("kafka scaling topic the application cannot read", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				Kafka: &nais_io_v1.Kafka{Pool: "nav-dev"},
+				Replicas: &nais_io_v1.Replicas{
+					Min: new(4),
+					Max: new(2),
+					ScalingStrategy: &nais_io_v1.ScalingStrategy{
+						Kafka: &nais_io_v1.KafkaScaling{Topic: "test-ns.missing", ConsumerGroup: "group", Threshold: 10},
+					},
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.replicas.min")
+		assert.Contains(t, err.Error(), "spec.replicas.scalingStrategy.kafka.topic")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("kafka scaling topic is read through the API reader", func(t *testing.T) {
+		topic := &kafka_nais_io_v1.Topic{
+			ObjectMeta: metav1.ObjectMeta{Name: "mytopic", Namespace: "test-ns"},
+			Spec: kafka_nais_io_v1.TopicSpec{
+				Pool: "nav-dev",
+				ACL:  []kafka_nais_io_v1.TopicACL{{Team: "test-ns", Application: "test-app", Access: "read"}},
+			},
+		}
+		validator := &ApplicationValidator{Client: fakeKubeClient(), apiReader: fakeKubeClient(topic)}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				Kafka: &nais_io_v1.Kafka{Pool: "nav-dev"},
+				Replicas: &nais_io_v1.Replicas{
+					ScalingStrategy: &nais_io_v1.ScalingStrategy{
+						Kafka: &nais_io_v1.KafkaScaling{Topic: "test-ns.mytopic", ConsumerGroup: "group", Threshold: 10},
+					},
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("team inbound rule with tokenx", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image:  "nginx:latest",
+				TokenX: &nais_io_v1.TokenX{Enabled: true},
+				AccessPolicy: &nais_io_v1.AccessPolicy{Inbound: &nais_io_v1.AccessPolicyInbound{Rules: []nais_io_v1.AccessPolicyInboundRule{
+					{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "frontend"}},
+					{AccessPolicyRule: nais_io_v1.AccessPolicyRule{Application: "*", Team: "test-ns"}},
+				}}},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.inbound.rules[1]: Forbidden: wildcards and team selectors cannot be used with spec.tokenx, as inbound rules also grant access to tokens")
+		assert.NotContains(t, err.Error(), "rules[0]")
+		assert.Empty(t, warnings)
+
+		app.Spec.TokenX.Enabled = false
+		warnings, err = validator.ValidateCreate(t.Context(), app)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("unknown kafka pool", func(t *testing.T) {
+		validator := &ApplicationValidator{
+			Client: fakeKubeClient(),
+			config: nais_io_v1.NewValidatorConfig(nais_io_v1.WithKafkaPools("nav-dev")),
+		}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				Kafka: &nais_io_v1.Kafka{Pool: "nav-prod"},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.kafka.pool")
+		assert.Empty(t, warnings)
+	})
+
 	t.Run("application name too long", func(t *testing.T) {
 		validator := &ApplicationValidator{Client: fakeKubeClient()}
 		app := &Application{
@@ -105,6 +385,54 @@ func TestApplicationValidator_ValidateCreate(t *testing.T) {
 		assert.Empty(t, warnings)
 	})
 
+	t.Run("filesFrom without a source", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				FilesFrom: []nais_io_v1.FilesFrom{
+					{ConfigMap: "my-configmap"},
+					{MountPath: "/var/run/foo"},
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.filesFrom[1]")
+		assert.NotContains(t, err.Error(), "spec.filesFrom[0]")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("external access policy with both host and ipv4", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				AccessPolicy: &nais_io_v1.AccessPolicy{
+					Outbound: &nais_io_v1.AccessPolicyOutbound{
+						External: []nais_io_v1.AccessPolicyExternalRule{
+							{Host: "example.com", IPv4: "10.0.0.1"},
+						},
+					},
+				},
+			},
+		}
+
+		warnings, err := validator.ValidateCreate(t.Context(), app)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.accessPolicy.outbound.external[0].ipv4")
+		assert.Empty(t, warnings)
+	})
+
 	t.Run("opensearch reference exists", func(t *testing.T) {
 		namespace := "test-ns"
 		instance := "my-opensearch"
@@ -362,6 +690,26 @@ func TestApplicationValidator_ValidateUpdate(t *testing.T) {
 		assert.Empty(t, warnings)
 	})
 
+	t.Run("update keeping its own ingresses", func(t *testing.T) {
+		oldApp := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image:     "nginx:latest",
+				Ingresses: []nais_io_v1.Ingress{"https://test-app.intern.nav.no"},
+			},
+		}
+		validator := &ApplicationValidator{Client: fakeKubeClient(oldApp)}
+		newApp := oldApp.DeepCopy()
+		newApp.Spec.Ingresses = append(newApp.Spec.Ingresses, "https://test-app.intern.nav.no/v2")
+
+		warnings, err := validator.ValidateUpdate(t.Context(), oldApp, newApp)
+		assert.NoError(t, err)
+		assert.Empty(t, warnings)
+	})
+
 	t.Run("update with spec changes that should fail validation", func(t *testing.T) {
 		validator := &ApplicationValidator{Client: fakeKubeClient()}
 		oldApp := &Application{
@@ -389,6 +737,73 @@ func TestApplicationValidator_ValidateUpdate(t *testing.T) {
 		assert.Empty(t, warnings)
 	})
 
+	t.Run("update removing azure tenant once set should fail validation", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		oldApp := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				Azure: &nais_io_v1.Azure{
+					Application: &nais_io_v1.AzureApplication{
+						Enabled: true,
+						Tenant:  "trygdeetaten.no",
+					},
+				},
+			},
+		}
+		newApp := oldApp.DeepCopy()
+		newApp.Spec.Azure.Application.Tenant = ""
+
+		warnings, err := validator.ValidateUpdate(t.Context(), oldApp, newApp)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.azure.application.tenant")
+		assert.Empty(t, warnings)
+	})
+
+	t.Run("update removing sql instance or shrinking disk should fail validation", func(t *testing.T) {
+		validator := &ApplicationValidator{Client: fakeKubeClient()}
+		oldApp := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+				GCP: &nais_io_v1.GCP{
+					SqlInstances: []nais_io_v1.CloudSqlInstance{
+						{
+							Type:     nais_io_v1.CloudSqlInstanceTypePostgres17,
+							Tier:     "db-f1-micro",
+							DiskSize: 20,
+						},
+					},
+				},
+			},
+		}
+
+		shrunk := oldApp.DeepCopy()
+		shrunk.Spec.GCP.SqlInstances[0].DiskSize = 10
+		_, err := validator.ValidateUpdate(t.Context(), oldApp, shrunk)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.gcp.sqlInstances.0.diskSize")
+
+		removed := oldApp.DeepCopy()
+		removed.Spec.GCP.SqlInstances = nil
+		_, err = validator.ValidateUpdate(t.Context(), oldApp, removed)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "spec.gcp.sqlInstances")
+
+		cascading := oldApp.DeepCopy()
+		cascading.Spec.GCP.SqlInstances[0].CascadingDelete = true
+		removed = cascading.DeepCopy()
+		removed.Spec.GCP.SqlInstances = nil
+		_, err = validator.ValidateUpdate(t.Context(), cascading, removed)
+		assert.NoError(t, err)
+	})
+
 	t.Run("update with aiven references", func(t *testing.T) {
 		namespace := "test-ns"
 		instance := "my-opensearch"
@@ -524,6 +939,90 @@ func TestApplicationValidator_ValidateDelete(t *testing.T) {
 }
 
 func TestApplicationMutator_Default(t *testing.T) {
+	t.Run("applies defaults policies", func(t *testing.T) {
+		namespacePolicy := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: nais_io_v1.DefaultsPolicyName, Namespace: "test-ns"},
+			Data: map[string]string{
+				nais_io_v1.DefaultsPolicyKey: "resources:\n  requests:\n    memory: 128Mi\n",
+			},
+		}
+		teamPolicy := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: nais_io_v1.DefaultsPolicyName + "-test-team", Namespace: "nais-system"},
+			Data: map[string]string{
+				nais_io_v1.DefaultsPolicyKey: "resources:\n  requests:\n    cpu: 50m\n    memory: 1Gi\nreplicas:\n  min: 1\n  max: 2\n",
+			},
+		}
+		mutator := &ApplicationMutator{
+			Client:   fakeKubeClient(namespacePolicy, teamPolicy),
+			Config:   nais_io_v1.NewValidatorConfig(nais_io_v1.WithDefaultsPolicies("nais-system")),
+			Defaults: (*Application).ApplyDefaultsPolicy,
+		}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+				Labels:    map[string]string{nais_io_v1.TeamLabel: "test-team"},
+			},
+			Spec: ApplicationSpec{
+				Image:    "nginx:latest",
+				Replicas: &nais_io_v1.Replicas{Min: new(3)},
+			},
+		}
+
+		err := mutator.Default(t.Context(), app)
+		require.NoError(t, err)
+		assert.Equal(t, &nais_io_v1.ResourceSpec{Cpu: "50m", Memory: "128Mi"}, app.Spec.Resources.Requests)
+		assert.Equal(t, 3, *app.Spec.Replicas.Min)
+		assert.Equal(t, 2, *app.Spec.Replicas.Max)
+		assert.Equal(t, "test-ns/nais-workload-defaults,nais-system/nais-workload-defaults-test-team", app.Annotations[nais_io_v1.DefaultsPolicyAnnotation])
+	})
+
+	t.Run("removes record of defaults policies that no longer apply", func(t *testing.T) {
+		mutator := &ApplicationMutator{
+			Client:   fakeKubeClient(),
+			Config:   nais_io_v1.NewValidatorConfig(nais_io_v1.WithDefaultsPolicies("nais-system")),
+			Defaults: (*Application).ApplyDefaultsPolicy,
+		}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "test-app",
+				Namespace:   "test-ns",
+				Annotations: map[string]string{nais_io_v1.DefaultsPolicyAnnotation: "test-ns/nais-workload-defaults"},
+			},
+			Spec: ApplicationSpec{
+				Image: "nginx:latest",
+			},
+		}
+
+		err := mutator.Default(t.Context(), app)
+		require.NoError(t, err)
+		assert.NotContains(t, app.Annotations, nais_io_v1.DefaultsPolicyAnnotation)
+		assert.Nil(t, app.Spec.Resources)
+	})
+
+	t.Run("invalid defaults policy", func(t *testing.T) {
+		policy := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: nais_io_v1.DefaultsPolicyName, Namespace: "test-ns"},
+			Data: map[string]string{
+				nais_io_v1.DefaultsPolicyKey: "replicas: many",
+			},
+		}
+		mutator := &ApplicationMutator{
+			Client:   fakeKubeClient(policy),
+			Config:   nais_io_v1.NewValidatorConfig(nais_io_v1.WithDefaultsPolicies("nais-system")),
+			Defaults: (*Application).ApplyDefaultsPolicy,
+		}
+		app := &Application{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-app",
+				Namespace: "test-ns",
+			},
+		}
+
+		err := mutator.Default(t.Context(), app)
+		assert.ErrorContains(t, err, "parse defaults policy test-ns/nais-workload-defaults")
+	})
+
 	t.Run("sets kill-after label when TTL is specified", func(t *testing.T) {
 		mutator := &ApplicationMutator{}
 		app := &Application{
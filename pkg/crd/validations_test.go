@@ -0,0 +1,58 @@
+package crd_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/nais/liberator/pkg/crd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// Wildcards and team selectors are only supported for Applications and Naisjobs,
+// so the CRDs of the resources that receive their access policy must reject them.
+func TestAccessPolicyValidations(t *testing.T) {
+	const (
+		message = "wildcards and team selectors are only supported in the access policy of Applications and Naisjobs"
+		rule    = "x.application != '*' && (!has(x.namespace) || x.namespace != '*') && !has(x.team)"
+	)
+
+	for name, tt := range map[string]struct {
+		file     string
+		property string
+		rules    []string
+	}{
+		"AzureAdApplication": {
+			file:     "nais.io_azureadapplications.yaml",
+			property: "preAuthorizedApplications",
+			rules:    []string{"self.all(x, " + rule + ")"},
+		},
+		"Jwker": {
+			file:     "nais.io_jwkers.yaml",
+			property: "accessPolicy",
+			rules: []string{
+				"!has(self.inbound) || self.inbound.rules.all(x, " + rule + ")",
+				"!has(self.outbound) || !has(self.outbound.rules) || self.outbound.rules.all(x, " + rule + ")",
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join(crd.YamlDirectory(), tt.file))
+			require.NoError(t, err)
+			definition := &apiextensionsv1.CustomResourceDefinition{}
+			require.NoError(t, yaml.Unmarshal(data, definition))
+			require.Len(t, definition.Spec.Versions, 1)
+
+			spec := definition.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"]
+			var rules []string
+			for _, validation := range spec.Properties[tt.property].XValidations {
+				assert.Equal(t, message, validation.Message)
+				rules = append(rules, validation.Rule)
+			}
+			assert.Equal(t, tt.rules, rules)
+		})
+	}
+}
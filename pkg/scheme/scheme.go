@@ -80,12 +80,12 @@ func TypeName(resource runtime.Object) string {
 	return fmt.Sprintf("resource '%s' named '%s' in namespace '%s'", kind, name, namespace)
 }
 
-func Webhooks(mgr ctrl.Manager) error {
+func Webhooks(mgr ctrl.Manager, opts ...nais_io_v1.ValidatorOption) error {
 	errors := []error{}
-	if err := nais_io_v1alpha1.SetupWebhookWithManager(mgr); err != nil {
+	if err := nais_io_v1alpha1.SetupWebhookWithManager(mgr, opts...); err != nil {
 		errors = append(errors, err)
 	}
-	if err := nais_io_v1.SetupWebhookWithManager(mgr); err != nil {
+	if err := nais_io_v1.SetupWebhookWithManager(mgr, opts...); err != nil {
 		errors = append(errors, err)
 	}
 
@@ -0,0 +1,76 @@
+package webhookvalidator
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DeprecatedField is a field tagged with `nais:"deprecated"` that is set in an object.
+type DeprecatedField struct {
+	// Path is the full JSON path of the field, e.g. `spec.azure.application.replyURLs`.
+	Path *field.Path
+	// Name is the Go name of the field, qualified with the name of the type containing it,
+	// e.g. `AzureApplication.ReplyURLs`.
+	Name string
+}
+
+// Deprecated returns all fields tagged with `nais:"deprecated"` that are set in the given object, in declaration order.
+func Deprecated(obj any, path *field.Path) []DeprecatedField {
+	value, ok := indirect(reflect.ValueOf(obj))
+	if !ok {
+		return nil
+	}
+
+	return deprecatedFields(value, path)
+}
+
+func deprecatedFields(value reflect.Value, path *field.Path) (fields []DeprecatedField) {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		return deprecatedFields(value.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			fields = append(fields, deprecatedFields(value.Index(i), path.Index(i))...)
+		}
+		return fields
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range sortedKeys(value) {
+			fields = append(fields, deprecatedFields(value.MapIndex(key), path.Key(key.String()))...)
+		}
+		return fields
+	case reflect.Struct:
+	default:
+		return nil
+	}
+
+	plan := planFor(value.Type())
+	if !plan.deprecated {
+		return nil
+	}
+
+	for _, f := range plan.fields {
+		fieldValue := value.Field(f.index)
+		fieldPath := path.Child(f.name)
+		if f.inline {
+			fieldPath = path
+		}
+
+		if f.deprecated && !isUnset(fieldValue) {
+			fields = append(fields, DeprecatedField{
+				Path: fieldPath,
+				Name: value.Type().Name() + "." + f.goName,
+			})
+		}
+
+		fields = append(fields, deprecatedFields(fieldValue, fieldPath)...)
+	}
+
+	return fields
+}
@@ -36,3 +36,42 @@ func ExampleNaisCompare() {
 	fmt.Println(err)
 	// Output: animals.Pets.1.Species: Invalid value: "Bird": field is immutable
 }
+
+func ExampleNaisCompare_onceSet() {
+	type Client struct {
+		// Immutable once set.
+		Tenant string `nais:"immutable,onceset"`
+	}
+
+	fmt.Println(webhookvalidator.NaisCompare(Client{Tenant: "foo"}, Client{}, field.NewPath("spec")))
+	fmt.Println(webhookvalidator.NaisCompare(Client{Tenant: "bar"}, Client{Tenant: "foo"}, field.NewPath("spec")))
+	fmt.Println(webhookvalidator.NaisCompare(Client{}, Client{Tenant: "foo"}, field.NewPath("spec")))
+	// Output:
+	// <nil>
+	// spec.Tenant: Invalid value: "bar": field is immutable once set
+	// spec.Tenant: Forbidden: field is immutable and cannot be removed once set
+}
+
+func ExampleDeprecated() {
+	type Hook struct {
+		// +nais:doc:Deprecated=true
+		Path string `json:"path,omitempty" nais:"deprecated"`
+		Port int    `json:"port,omitempty"`
+	}
+
+	type Spec struct {
+		Hooks []Hook `json:"hooks"`
+	}
+
+	spec := Spec{
+		Hooks: []Hook{
+			{Port: 8080},
+			{Path: "/stop"},
+		},
+	}
+
+	for _, deprecated := range webhookvalidator.Deprecated(spec, field.NewPath("spec")) {
+		fmt.Println(deprecated.Path, deprecated.Name)
+	}
+	// Output: spec.hooks[1].path Hook.Path
+}
@@ -0,0 +1,230 @@
+package webhookvalidator
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Lint checks the `nais:"..."` struct tags of the given types, and all types reachable from them,
+// for mistakes that would otherwise only be discovered at runtime, or silently ignored.
+//
+// Pass a value of each type to check, e.g. `Lint(ApplicationSpec{})`. It is meant to be called from unit tests
+// in the packages that define the types. The following problems are reported:
+//
+//   - unknown or misspelled properties, and properties with missing or unexpected values
+//   - slices of structs that must be compared element by element, but have no `nais:"key"` field
+//   - `onceset` or `strict` without `immutable`, or both at the same time
+//   - `monotonic` on non-numeric fields, or with a direction other than `increase` or `decrease`
+//   - `appendonly` on fields that are not slices or maps, or that name a non-existent boolean field
+//   - `requires` paths that do not exist
+//   - format rules on fields that are not strings or lists of strings
+//   - `mutuallyExclusive` and `oneOf` groups with fewer than two members
+func Lint(types ...any) error {
+	l := &linter{seen: map[reflect.Type]bool{}}
+	for _, t := range types {
+		typ := reflect.TypeOf(t)
+		if typ != nil {
+			typ = indirectType(typ)
+		}
+		if typ == nil || typ.Kind() != reflect.Struct {
+			l.errs = append(l.errs, fmt.Errorf("%v: not a struct type", reflect.TypeOf(t)))
+			continue
+		}
+		l.lintType(typ)
+	}
+	return errors.NewAggregate(l.errs)
+}
+
+type linter struct {
+	seen map[reflect.Type]bool
+	errs []error
+}
+
+func (l *linter) errorf(typ reflect.Type, f *fieldPlan, format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf("%s.%s: %s", typ.Name(), f.goName, fmt.Sprintf(format, args...)))
+}
+
+func (l *linter) lintType(typ reflect.Type) {
+	if l.seen[typ] {
+		return
+	}
+	l.seen[typ] = true
+
+	plan := planFor(typ)
+	groups := map[string]map[string]int{
+		"mutuallyExclusive": {},
+		"oneOf":             {},
+	}
+
+	for i := range plan.fields {
+		f := &plan.fields[i]
+		l.lintProperties(typ, f)
+
+		if f.immutable && f.onceSet && f.strict {
+			l.errorf(typ, f, "onceset and strict cannot be used together")
+		}
+		if !f.immutable && (f.onceSet || f.strict) {
+			l.errorf(typ, f, "onceset and strict require immutable")
+		}
+
+		if len(f.monotonic) > 0 {
+			if f.monotonic != "increase" && f.monotonic != "decrease" {
+				l.errorf(typ, f, "monotonic must be either increase or decrease, not %q", f.monotonic)
+			}
+			if !isNumber(indirectType(f.typ)) {
+				l.errorf(typ, f, "monotonic can only be used on numbers, not %s", f.typ)
+			}
+		}
+
+		if f.appendOnly {
+			l.lintAppendOnly(typ, f)
+		}
+
+		if len(f.requires) > 0 && !hasPath(typ, f.requires) {
+			l.errorf(typ, f, "requires non-existent field %q", f.requires)
+		}
+
+		if f.hasFormatRules() && !isStringOrStrings(f.typ) {
+			l.errorf(typ, f, "format rules can only be used on strings or lists of strings, not %s", f.typ)
+		}
+
+		if len(f.mutuallyExclusive) > 0 {
+			groups["mutuallyExclusive"][f.mutuallyExclusive]++
+		}
+		if len(f.oneOf) > 0 {
+			groups["oneOf"][f.oneOf]++
+		}
+
+		fieldType := f.typ
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if !f.immutable && (fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array) {
+			if elem := indirectType(fieldType.Elem()); elem.Kind() == reflect.Struct {
+				elemPlan := planFor(elem)
+				if elemPlan.compare && len(elemPlan.keys) == 0 {
+					l.errorf(typ, f, "elements of %s have immutable fields, but no field is tagged with `nais:\"key\"`", elem.Name())
+				}
+			}
+		}
+
+		if elem := structElem(f.typ); elem != nil {
+			l.lintType(elem)
+		}
+	}
+
+	for _, kind := range []string{"mutuallyExclusive", "oneOf"} {
+		for _, group := range sortedStrings(groups[kind]) {
+			if groups[kind][group] < 2 {
+				l.errs = append(l.errs, fmt.Errorf("%s: %s group %q has only one member", typ.Name(), kind, group))
+			}
+		}
+	}
+}
+
+func (l *linter) lintProperties(typ reflect.Type, f *fieldPlan) {
+	for _, name := range sortedStrings(f.properties) {
+		value := f.properties[name]
+		kind, ok := knownProperties[name]
+		switch {
+		case !ok:
+			l.errorf(typ, f, "unknown property %q", name)
+		case kind == noValue && len(value) > 0:
+			l.errorf(typ, f, "property %q does not take a value", name)
+		case kind == requiredValue && len(value) == 0:
+			l.errorf(typ, f, "property %q requires a value", name)
+		}
+	}
+}
+
+func (l *linter) lintAppendOnly(typ reflect.Type, f *fieldPlan) {
+	fieldType := f.typ
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+	default:
+		l.errorf(typ, f, "appendonly can only be used on lists and maps, not %s", f.typ)
+		return
+	}
+
+	if len(f.allowRemoval) == 0 {
+		return
+	}
+	elem := indirectType(fieldType.Elem())
+	if elem.Kind() != reflect.Struct {
+		l.errorf(typ, f, "appendonly=%s requires elements to be structs", f.allowRemoval)
+		return
+	}
+	flag, ok := fieldTypeByJSONName(elem, f.allowRemoval)
+	if !ok || flag.Kind() != reflect.Bool {
+		l.errorf(typ, f, "appendonly=%s must name a boolean field on %s", f.allowRemoval, elem.Name())
+	}
+}
+
+// hasPath returns true if a dot-separated path of JSON field names exists, starting at the given struct type.
+func hasPath(typ reflect.Type, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		typ = indirectType(typ)
+		if typ.Kind() != reflect.Struct {
+			return false
+		}
+		var ok bool
+		typ, ok = fieldTypeByJSONName(typ, name)
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// fieldTypeByJSONName is the type-level equivalent of fieldByJSONName.
+func fieldTypeByJSONName(typ reflect.Type, name string) (reflect.Type, bool) {
+	for _, f := range planFor(typ).fields {
+		if f.inline {
+			if inner := indirectType(f.typ); inner.Kind() == reflect.Struct {
+				if found, ok := fieldTypeByJSONName(inner, name); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+		if f.name == name {
+			return f.typ, true
+		}
+	}
+	return nil, false
+}
+
+func isNumber(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+func isStringOrStrings(typ reflect.Type) bool {
+	typ = indirectType(typ)
+	if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = indirectType(typ.Elem())
+	}
+	return typ.Kind() == reflect.String
+}
+
+func sortedStrings[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
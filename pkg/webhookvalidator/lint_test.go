@@ -0,0 +1,88 @@
+package webhookvalidator
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/errors"
+)
+
+func TestLint(t *testing.T) {
+	valid := []any{
+		SmallStruct{},
+		&mediumStruct{},
+		ptrStructImmutable{},
+		collectionStruct{},
+		onceSetStruct{},
+		appendOnlyStruct{},
+		ruleStruct{},
+	}
+	for _, typ := range valid {
+		if err := Lint(typ); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	err := Lint(lintStruct{}, "not a struct")
+	if err == nil {
+		t.Fatal("expected error, but got nil")
+	}
+
+	expected := []string{
+		"string: not a struct type",
+		`lintStruct.Misspelled: unknown property "imutable"`,
+		`lintStruct.FlagWithValue: property "immutable" does not take a value`,
+		`lintStruct.MissingValue: property "requires" requires a value`,
+		"lintStruct.OnceSet: onceset and strict require immutable",
+		"lintStruct.Both: onceset and strict cannot be used together",
+		`lintStruct.Direction: monotonic must be either increase or decrease, not "up"`,
+		"lintStruct.NotNumber: monotonic can only be used on numbers, not string",
+		"lintStruct.NotList: appendonly can only be used on lists and maps, not string",
+		"lintStruct.NoFlag: appendonly=missing must name a boolean field on deletable",
+		`lintStruct.Requires: requires non-existent field "nope"`,
+		"lintStruct.Format: format rules can only be used on strings or lists of strings, not int",
+		"lintStruct.NoKey: elements of ruleStruct have immutable fields, but no field is tagged with `nais:\"key\"`",
+		`lintStruct: mutuallyExclusive group "lonely" has only one member`,
+	}
+	errs := err.(errors.Aggregate).Errors()
+	found := map[string]bool{}
+	for _, e := range errs {
+		found[e.Error()] = true
+	}
+	for _, msg := range expected {
+		if !found[msg] {
+			t.Errorf("expected error: %q", msg)
+		}
+		delete(found, msg)
+	}
+	for msg := range found {
+		t.Errorf("got %q, but did not expect it", msg)
+	}
+}
+
+func TestLintRecursiveType(t *testing.T) {
+	type node struct {
+		Name     string `nais:"key"`
+		Children []node `nais:"imutable"`
+	}
+	err := Lint(node{})
+	if err == nil || !strings.Contains(err.Error(), `unknown property "imutable"`) {
+		t.Errorf("expected unknown property error, got %v", err)
+	}
+}
+
+type lintStruct struct {
+	Misspelled    string       `nais:"imutable"`
+	FlagWithValue string       `nais:"immutable=true"`
+	MissingValue  string       `nais:"requires"`
+	OnceSet       string       `nais:"onceset"`
+	Both          string       `nais:"immutable,onceset,strict"`
+	Direction     int          `nais:"monotonic=up"`
+	NotNumber     string       `nais:"monotonic=increase"`
+	NotList       string       `nais:"appendonly"`
+	NoFlag        []deletable  `nais:"appendonly=missing"`
+	Requires      string       `nais:"requires=nope"`
+	Format        int          `nais:"duration"`
+	NoKey         []ruleStruct `json:"noKey"`
+	Lonely        string       `nais:"mutuallyExclusive=lonely"`
+}
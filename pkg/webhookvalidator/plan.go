@@ -0,0 +1,220 @@
+package webhookvalidator
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// propertyValue describes whether a `nais:"..."` property takes a value, e.g. `nais:"monotonic=increase"`.
+type propertyValue int
+
+const (
+	noValue propertyValue = iota
+	optionalValue
+	requiredValue
+)
+
+// knownProperties lists every property understood in `nais:"..."` struct tags.
+var knownProperties = map[string]propertyValue{
+	// NaisCompare
+	"immutable":  noValue,
+	"key":        noValue,
+	"onceset":    noValue,
+	"strict":     noValue,
+	"appendonly": optionalValue,
+	"monotonic":  requiredValue,
+
+	// Validate
+	"mutuallyexclusive": requiredValue,
+	"oneof":             requiredValue,
+	"requires":          requiredValue,
+	"duration":          noValue,
+	"url":               optionalValue,
+	"cron":              noValue,
+
+	// Deprecated
+	"deprecated": noValue,
+}
+
+// fieldPlan holds the parsed `nais:"..."` tag of a single exported struct field.
+type fieldPlan struct {
+	index  int
+	goName string
+	// name is the JSON name of the field, or the Go name if it has none.
+	name   string
+	inline bool
+	typ    reflect.Type
+
+	// properties maps lower-cased property names to their values.
+	properties map[string]string
+
+	immutable    bool
+	onceSet      bool
+	strict       bool
+	key          bool
+	appendOnly   bool
+	allowRemoval string
+	monotonic    string
+
+	mutuallyExclusive string
+	oneOf             string
+	requires          string
+	duration          bool
+	url               bool
+	scheme            string
+	cron              bool
+
+	deprecated bool
+}
+
+func (f *fieldPlan) hasCompareRules() bool {
+	return f.immutable || f.appendOnly || len(f.monotonic) > 0
+}
+
+func (f *fieldPlan) hasValidateRules() bool {
+	return len(f.mutuallyExclusive) > 0 || len(f.oneOf) > 0 || len(f.requires) > 0 || f.hasFormatRules()
+}
+
+func (f *fieldPlan) hasFormatRules() bool {
+	return f.duration || f.url || f.cron
+}
+
+// typePlan is the precomputed comparison and validation plan for a struct type.
+type typePlan struct {
+	fields []fieldPlan
+	// keys contains the Go names of fields tagged with `nais:"key"`.
+	keys []string
+	// compare is true if NaisCompare has anything to check in this type or any of its descendants.
+	compare bool
+	// validate is true if Validate has anything to check in this type or any of its descendants.
+	validate bool
+	// deprecated is true if this type or any of its descendants has deprecated fields.
+	deprecated bool
+}
+
+// plans caches a *typePlan per reflect.Type, so struct tags are only parsed once per type.
+var plans sync.Map
+
+// planFor returns the plan for a struct type, building it on first use.
+func planFor(typ reflect.Type) *typePlan {
+	if plan, ok := plans.Load(typ); ok {
+		return plan.(*typePlan)
+	}
+	return buildPlan(typ, map[reflect.Type]bool{})
+}
+
+// buildPlan parses the tags of a struct type and all struct types reachable from it, and caches the results.
+// Types that are currently being built are tracked in `building` to support recursive types.
+func buildPlan(typ reflect.Type, building map[reflect.Type]bool) *typePlan {
+	if plan, ok := plans.Load(typ); ok {
+		return plan.(*typePlan)
+	}
+	if building[typ] {
+		// Recursive type; assume that there is something to check further down
+		return &typePlan{compare: true, validate: true, deprecated: true}
+	}
+	building[typ] = true
+	defer delete(building, typ)
+
+	plan := &typePlan{}
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		if !structField.IsExported() {
+			continue
+		}
+
+		f := parseField(i, structField)
+		plan.fields = append(plan.fields, f)
+		if f.key {
+			plan.keys = append(plan.keys, f.goName)
+		}
+
+		plan.compare = plan.compare || f.hasCompareRules()
+		plan.validate = plan.validate || f.hasValidateRules()
+		plan.deprecated = plan.deprecated || f.deprecated
+		if elem := structElem(f.typ); elem != nil {
+			child := buildPlan(elem, building)
+			plan.compare = plan.compare || child.compare
+			plan.validate = plan.validate || child.validate
+			plan.deprecated = plan.deprecated || child.deprecated
+		}
+	}
+
+	actual, _ := plans.LoadOrStore(typ, plan)
+	return actual.(*typePlan)
+}
+
+func parseField(index int, structField reflect.StructField) fieldPlan {
+	properties := parseProperties(structField.Tag.Get("nais"))
+	f := fieldPlan{
+		index:      index,
+		goName:     structField.Name,
+		name:       jsonName(structField),
+		inline:     isInline(structField),
+		typ:        structField.Type,
+		properties: properties,
+	}
+
+	_, f.immutable = properties["immutable"]
+	_, f.onceSet = properties["onceset"]
+	_, f.strict = properties["strict"]
+	_, f.key = properties["key"]
+	f.allowRemoval, f.appendOnly = properties["appendonly"]
+	f.monotonic = properties["monotonic"]
+	f.mutuallyExclusive = properties["mutuallyexclusive"]
+	f.oneOf = properties["oneof"]
+	f.requires = properties["requires"]
+	_, f.duration = properties["duration"]
+	f.scheme, f.url = properties["url"]
+	_, f.cron = properties["cron"]
+	_, f.deprecated = properties["deprecated"]
+
+	return f
+}
+
+// parseProperties creates a map["name of property"]"value" for easy look-up of `nais:"X=Y"` tags.
+// Property names are case-insensitive, values are not. Properties without a value map to the empty string.
+func parseProperties(tag string) map[string]string {
+	properties := map[string]string{}
+	for _, t := range strings.Split(tag, ",") {
+		if len(t) == 0 {
+			continue
+		}
+		key, value, _ := strings.Cut(t, "=")
+		properties[strings.ToLower(key)] = value
+	}
+	return properties
+}
+
+// structElem returns the struct type contained in a field type, looking through pointers, slices, arrays and maps.
+// Returns nil if the type does not contain a struct.
+func structElem(typ reflect.Type) reflect.Type {
+	for {
+		switch typ.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+		case reflect.Struct:
+			return typ
+		default:
+			return nil
+		}
+	}
+}
+
+// jsonName returns the name of the field by default, override if `json:"name"` is set
+func jsonName(field reflect.StructField) string {
+	t := field.Tag.Get("json")
+	tag := field.Name
+	parts := strings.Split(t, ",")
+	if parts[0] != "" {
+		tag = parts[0]
+	}
+
+	return tag
+}
+
+// isInline returns true for embedded structs whose fields are serialized as part of the containing struct.
+func isInline(field reflect.StructField) bool {
+	return field.Anonymous && strings.Split(field.Tag.Get("json"), ",")[0] == ""
+}
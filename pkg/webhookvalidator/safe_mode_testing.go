@@ -1,5 +0,0 @@
-//go:build testing
-
-package webhookvalidator
-
-const doPanic = true
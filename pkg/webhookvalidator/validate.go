@@ -0,0 +1,232 @@
+package webhookvalidator
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Validate checks an object against the declarative rules in its `nais:"..."` struct tags.
+//
+// The following rules are supported:
+//
+//	mutuallyExclusive=<group>  at most one field in the group may be set
+//	oneOf=<group>              exactly one field in the group must be set
+//	requires=<path>            if this field is set, the field at the dot-separated JSON path,
+//	                           relative to the containing struct, must also be set
+//	duration                   value must be a valid Go duration, e.g. `12h`
+//	url[=<scheme>]             value must be an absolute URL, optionally with the given scheme
+//	cron                       value must be a valid cron schedule, as accepted by Kubernetes CronJobs,
+//	                           without time zones or @every intervals
+//
+// Groups are local to the struct that contains the fields.
+// Format rules are only evaluated on fields that are set, and apply to each element if the field is a slice.
+func Validate(obj any, path *field.Path) error {
+	value, ok := indirect(reflect.ValueOf(obj))
+	if !ok {
+		return nil
+	}
+
+	return validateObject(value, path).ToAggregate()
+}
+
+func validateObject(value reflect.Value, path *field.Path) (allErrs field.ErrorList) {
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		return validateObject(value.Elem(), path)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			allErrs = append(allErrs, validateObject(value.Index(i), path.Index(i))...)
+		}
+		return allErrs
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		for _, key := range sortedKeys(value) {
+			allErrs = append(allErrs, validateObject(value.MapIndex(key), path.Key(key.String()))...)
+		}
+		return allErrs
+	case reflect.Struct:
+		return validateStruct(value, path)
+	default:
+		return nil
+	}
+}
+
+func validateStruct(value reflect.Value, path *field.Path) (allErrs field.ErrorList) {
+	plan := planFor(value.Type())
+	if !plan.validate {
+		// Nothing in this type or any of its descendants has validation rules
+		return nil
+	}
+
+	mutuallyExclusive := newGroups()
+	oneOf := newGroups()
+
+	for _, f := range plan.fields {
+		fieldValue := value.Field(f.index)
+		fieldPath := path.Child(f.name)
+		if f.inline {
+			fieldPath = path
+		}
+
+		set := !isUnset(fieldValue)
+
+		if len(f.mutuallyExclusive) > 0 {
+			mutuallyExclusive.add(f.mutuallyExclusive, f.name, set)
+		}
+		if len(f.oneOf) > 0 {
+			oneOf.add(f.oneOf, f.name, set)
+		}
+
+		if set {
+			if len(f.requires) > 0 && !pathIsSet(value, f.requires) {
+				targetPath := strings.Split(f.requires, ".")
+				allErrs = append(allErrs, field.Required(path.Child(targetPath[0], targetPath[1:]...), fmt.Sprintf("required when %s is set", f.name)))
+			}
+			if f.hasFormatRules() {
+				allErrs = append(allErrs, validateFormat(fieldValue, fieldPath, &f)...)
+			}
+		}
+
+		allErrs = append(allErrs, validateObject(fieldValue, fieldPath)...)
+	}
+
+	for _, group := range mutuallyExclusive.order {
+		set := mutuallyExclusive.set[group]
+		for i := 1; i < len(set); i++ {
+			allErrs = append(allErrs, field.Forbidden(path.Child(set[i]), fmt.Sprintf("may not be set together with %s", set[0])))
+		}
+	}
+
+	for _, group := range oneOf.order {
+		members := oneOf.members[group]
+		set := oneOf.set[group]
+		if len(set) == 0 {
+			allErrs = append(allErrs, field.Required(path, fmt.Sprintf("exactly one of %s must be set", strings.Join(members, ", "))))
+			continue
+		}
+		for _, name := range set[1:] {
+			allErrs = append(allErrs, field.Forbidden(path.Child(name), fmt.Sprintf("exactly one of %s may be set, but %s is already set", strings.Join(members, ", "), set[0])))
+		}
+	}
+
+	return allErrs
+}
+
+// validateFormat checks the value of a field against the format rules in its tags.
+// If the field is a slice or array, each element is checked individually.
+func validateFormat(value reflect.Value, path *field.Path, f *fieldPlan) (allErrs field.ErrorList) {
+	if value.Kind() == reflect.Slice || value.Kind() == reflect.Array {
+		for i := 0; i < value.Len(); i++ {
+			allErrs = append(allErrs, validateFormat(value.Index(i), path.Index(i), f)...)
+		}
+		return allErrs
+	}
+
+	value, ok := indirect(value)
+	if !ok || value.Kind() != reflect.String {
+		return nil
+	}
+	str := value.String()
+
+	if f.duration {
+		if _, err := time.ParseDuration(str); err != nil {
+			allErrs = append(allErrs, field.Invalid(path, str, "not a valid duration, e.g. '12h' or '30m'"))
+		}
+	}
+
+	if f.url {
+		u, err := url.Parse(str)
+		switch {
+		case err != nil || !u.IsAbs() || len(u.Host) == 0:
+			allErrs = append(allErrs, field.Invalid(path, str, "not a valid absolute URL"))
+		case len(f.scheme) > 0 && u.Scheme != f.scheme:
+			allErrs = append(allErrs, field.Invalid(path, str, fmt.Sprintf("URL scheme must be %s", f.scheme)))
+		}
+	}
+
+	if f.cron {
+		// Parsed like the CronJob controller does, which also rejects time zones in the schedule.
+		// Intervals are rejected as well, as they are relative to when the controller last started.
+		switch _, err := cron.ParseStandard(str); {
+		case strings.Contains(str, "TZ"):
+			allErrs = append(allErrs, field.Invalid(path, str, "time zones in the schedule are not supported; use the timeZone field instead"))
+		case strings.HasPrefix(str, "@every"):
+			allErrs = append(allErrs, field.Invalid(path, str, "intervals using @every are not supported; use a cron expression, e.g. '*/5 * * * *'"))
+		case err != nil:
+			allErrs = append(allErrs, field.Invalid(path, str, fmt.Sprintf("not a valid cron schedule: %s", err)))
+		}
+	}
+
+	return allErrs
+}
+
+// pathIsSet resolves a dot-separated path of JSON field names, starting at the given struct,
+// and returns true if the value at the end of the path is set.
+func pathIsSet(value reflect.Value, path string) bool {
+	for _, name := range strings.Split(path, ".") {
+		var ok bool
+		value, ok = indirect(value)
+		if !ok || value.Kind() != reflect.Struct {
+			return false
+		}
+		value, ok = fieldByJSONName(value, name)
+		if !ok {
+			return false
+		}
+	}
+	return !isUnset(value)
+}
+
+// fieldByJSONName finds the field with the given JSON name, also looking in inlined structs.
+func fieldByJSONName(value reflect.Value, name string) (reflect.Value, bool) {
+	for _, f := range planFor(value.Type()).fields {
+		if f.inline {
+			inner, ok := indirect(value.Field(f.index))
+			if ok && inner.Kind() == reflect.Struct {
+				if found, ok := fieldByJSONName(inner, name); ok {
+					return found, true
+				}
+			}
+			continue
+		}
+		if f.name == name {
+			return value.Field(f.index), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// groups keeps track of which fields are set in each named group, in declaration order.
+type groups struct {
+	order   []string
+	members map[string][]string
+	set     map[string][]string
+}
+
+func newGroups() *groups {
+	return &groups{
+		members: map[string][]string{},
+		set:     map[string][]string{},
+	}
+}
+
+func (g *groups) add(group, name string, set bool) {
+	if _, ok := g.members[group]; !ok {
+		g.order = append(g.order, group)
+	}
+	g.members[group] = append(g.members[group], name)
+	if set {
+		g.set[group] = append(g.set[group], name)
+	}
+}
@@ -0,0 +1,167 @@
+package webhookvalidator
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		Obj        any
+		TestErrors []string
+	}{
+		"Empty object pass": {
+			Obj: ruleStruct{Source: source{ConfigMap: "foo"}},
+		},
+		"Mutually exclusive fields fail if both are set": {
+			Obj: ruleStruct{
+				Value:     "foo",
+				ValueFrom: &SmallStruct{},
+				Source:    source{ConfigMap: "foo"},
+			},
+			TestErrors: []string{
+				"test.valueFrom",
+			},
+		},
+		"One-of group fails if none are set": {
+			Obj: ruleStruct{},
+			TestErrors: []string{
+				"test.source",
+			},
+		},
+		"One-of group fails if more than one is set": {
+			Obj: ruleStruct{Source: source{ConfigMap: "foo", Secret: "bar", EmptyDir: &SmallStruct{}, MountPath: "/tmp"}},
+			TestErrors: []string{
+				"test.source.secret",
+				"test.source.emptyDir",
+			},
+		},
+		"Requires fails if target is unset": {
+			Obj: ruleStruct{Source: source{EmptyDir: &SmallStruct{}}},
+			TestErrors: []string{
+				"test.source.mountPath",
+			},
+		},
+		"Requires pass if target is set": {
+			Obj: ruleStruct{Source: source{EmptyDir: &SmallStruct{}, MountPath: "/tmp"}},
+		},
+		"Formats pass if valid": {
+			Obj: ruleStruct{
+				Source:   source{Secret: "foo"},
+				TTL:      "1h",
+				Schedule: "*/5 * * * *",
+				URLs:     []string{"https://example.com/foo"},
+				Homepage: "http://example.com",
+			},
+		},
+		"Formats fail if invalid": {
+			Obj: ruleStruct{
+				Source:   source{Secret: "foo"},
+				TTL:      "a while",
+				Schedule: "every day",
+				URLs:     []string{"https://example.com", "http://example.com", "example.com"},
+				Homepage: "/foo",
+			},
+			TestErrors: []string{
+				"test.ttl",
+				"test.schedule",
+				"test.urls[1]",
+				"test.urls[2]",
+				"test.homepage",
+			},
+		},
+		"Schedules with a time zone fail": {
+			Obj: ruleStruct{
+				Source:   source{Secret: "foo"},
+				Schedule: "CRON_TZ=Europe/Oslo 0 12 * * *",
+			},
+			TestErrors: []string{
+				"test.schedule",
+			},
+		},
+		"Schedules with an interval fail": {
+			Obj: ruleStruct{
+				Source:   source{Secret: "foo"},
+				Schedule: "@every 10s",
+			},
+			TestErrors: []string{
+				"test.schedule",
+			},
+		},
+		"Rules are evaluated in slices and maps": {
+			Obj: collectionRuleStruct{
+				Slice: []ruleStruct{
+					{Source: source{Secret: "foo"}},
+					{},
+				},
+				Map: map[string]*ruleStruct{
+					"foo": {Source: source{Secret: "foo"}, TTL: "1"},
+					"bar": nil,
+				},
+			},
+			TestErrors: []string{
+				"test.slice[1].source",
+				"test.map[foo].ttl",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Validate(tt.Obj, field.NewPath("test"))
+			if len(tt.TestErrors) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("expected error, but got nil")
+			}
+
+			errors := err.(errors.Aggregate).Errors()
+			if len(tt.TestErrors) != len(errors) {
+				t.Errorf("expected %v errors, got %v: %v", len(tt.TestErrors), len(errors), err)
+			}
+
+			found := map[string]bool{}
+			for _, terr := range errors {
+				found[terr.(*field.Error).Field] = true
+			}
+			for _, expected := range tt.TestErrors {
+				if !found[expected] {
+					t.Errorf("expected error: %q", expected)
+				}
+				delete(found, expected)
+			}
+			for val := range found {
+				t.Errorf("got %q, but did not expect it", val)
+			}
+		})
+	}
+}
+
+type source struct {
+	ConfigMap string       `json:"configMap,omitempty" nais:"oneOf=source"`
+	Secret    string       `json:"secret,omitempty" nais:"oneOf=source"`
+	EmptyDir  *SmallStruct `json:"emptyDir,omitempty" nais:"oneOf=source,requires=mountPath"`
+	MountPath string       `json:"mountPath,omitempty"`
+}
+
+type ruleStruct struct {
+	Value     string       `json:"value,omitempty" nais:"mutuallyExclusive=value"`
+	ValueFrom *SmallStruct `json:"valueFrom,omitempty" nais:"mutuallyExclusive=value"`
+	Source    source       `json:"source"`
+	TTL       string       `json:"ttl,omitempty" nais:"duration"`
+	Schedule  string       `json:"schedule,omitempty" nais:"cron"`
+	URLs      []string     `json:"urls,omitempty" nais:"url=https"`
+	Homepage  string       `json:"homepage,omitempty" nais:"url"`
+}
+
+type collectionRuleStruct struct {
+	Slice []ruleStruct           `json:"slice"`
+	Map   map[string]*ruleStruct `json:"map"`
+}
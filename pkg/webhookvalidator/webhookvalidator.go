@@ -1,8 +1,10 @@
 package webhookvalidator
 
 import (
+	"cmp"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/util/validation/field"
@@ -29,26 +31,49 @@ func compareObjects(new, old reflect.Value, path *field.Path) (allErrs field.Err
 		// Must be handled as a special case when comparing objects in slices
 		return sliceMutationError(new, old, path)
 	}
+	if new.Kind() == reflect.Map {
+		// Map entries are matched on their key, so no `nais:"key"` field is needed
+		return mapMutationError(new, old, path)
+	}
 	if new.Kind() != reflect.Struct {
 		// Only structs (iow: their fields) can have the "immutable" tag set
 		return nil
 	}
 
-	newStruct := new.Type()
-	// Iterate over all the fields of the current object being compared
-	for i := 0; i < new.NumField(); i++ {
-		newField := new.Field(i)
+	plan := planFor(new.Type())
+	if !plan.compare {
+		// Nothing in this type or any of its descendants needs to be compared
+		return nil
+	}
+
+	// Iterate over all the exported fields of the current object being compared
+	for _, f := range plan.fields {
+		newField := new.Field(f.index)
+		oldField := old.Field(f.index)
+		newPath := path.Child(f.name)
+
+		if len(f.monotonic) > 0 {
+			if err := monotonicMutationError(newField, oldField, newPath, f.monotonic); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+
+		if f.appendOnly {
+			allErrs = append(allErrs, appendOnlyMutationError(newField, oldField, newPath, f.allowRemoval)...)
+		}
 
-		if !newField.CanInterface() {
-			// Ignore field if not exported
+		if f.immutable && (f.onceSet || f.strict) {
+			// Presence of the field is significant, so nil pointers and zero values must be considered as well
+			if err := onceSetMutationError(newField, oldField, newPath, f.strict); err != nil {
+				allErrs = append(allErrs, err)
+			}
 			continue
 		}
 
-		oldField := old.Field(i)
 		if newField.Kind() == reflect.Pointer {
 			// Derefence pointer if this current field is a pointer
 			if newField.IsNil() || oldField.IsNil() {
-				// TODO(thokra): Ensure that we allow the removal and addition of fields in nais.yaml spec
+				// Fields may be added or removed freely unless tagged with `onceset` or `strict`
 				continue
 			}
 
@@ -56,9 +81,7 @@ func compareObjects(new, old reflect.Value, path *field.Path) (allErrs field.Err
 			oldField = oldField.Elem()
 		}
 
-		tags := propertyMap(newStruct.Field(i))
-		newPath := path.Child(jsonName(newStruct.Field(i)))
-		if !tags["immutable"] {
+		if !f.immutable {
 			// Recursively descend into the fields of the current struct
 			if err := compareObjects(newField, oldField, newPath); err != nil {
 				allErrs = append(allErrs, err...)
@@ -66,7 +89,7 @@ func compareObjects(new, old reflect.Value, path *field.Path) (allErrs field.Err
 			}
 		}
 
-		if tags["immutable"] && valuesDiffer(newField, oldField) {
+		if f.immutable && valuesDiffer(newField, oldField) {
 			// If field is set to immutable, check if there's a change
 			allErrs = append(allErrs, field.Invalid(newPath, newField.Interface(), "field is immutable"))
 			continue
@@ -75,60 +98,211 @@ func compareObjects(new, old reflect.Value, path *field.Path) (allErrs field.Err
 	return allErrs
 }
 
-// propertyMap creates a map["name of property"]boolean struct for easy look-up of `nais:"X"` tags
-func propertyMap(field reflect.StructField) map[string]bool {
-	tagss := strings.Split(strings.ToLower(field.Tag.Get("nais")), ",")
-	tags := map[string]bool{}
-	for _, t := range tagss {
-		tags[t] = true
+// onceSetMutationError handles fields tagged with `nais:"immutable,onceset"` or `nais:"immutable,strict"`.
+//
+// A `onceset` field may be set if it was previously unset, but can never be changed or removed afterwards.
+// A `strict` field additionally cannot be set if it was unset in the old object.
+func onceSetMutationError(new, old reflect.Value, path *field.Path, strict bool) *field.Error {
+	newSet := !isUnset(new)
+	oldSet := !isUnset(old)
+
+	switch {
+	case !oldSet && !newSet:
+		return nil
+	case !oldSet:
+		if strict {
+			return field.Forbidden(path, "field is immutable and cannot be set after creation")
+		}
+		return nil
+	case !newSet:
+		return field.Forbidden(path, "field is immutable and cannot be removed once set")
+	}
+
+	new, _ = indirect(new)
+	old, _ = indirect(old)
+	if valuesDiffer(new, old) {
+		return field.Invalid(path, new.Interface(), "field is immutable once set")
+	}
+	return nil
+}
+
+// monotonicMutationError handles fields tagged with `nais:"monotonic=increase"` or `nais:"monotonic=decrease"`.
+// Numeric values may only change in the given direction, and cannot be removed once set.
+func monotonicMutationError(new, old reflect.Value, path *field.Path, direction string) *field.Error {
+	old, ok := indirect(old)
+	if !ok {
+		return nil
+	}
+	new, ok = indirect(new)
+	if !ok {
+		return field.Forbidden(path, "field cannot be removed once set")
+	}
+
+	cmp, ok := compareNumbers(new, old)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case direction == "increase" && cmp < 0:
+		return field.Invalid(path, new.Interface(), fmt.Sprintf("value can only be increased; previous value was %v", old.Interface()))
+	case direction == "decrease" && cmp > 0:
+		return field.Invalid(path, new.Interface(), fmt.Sprintf("value can only be decreased; previous value was %v", old.Interface()))
+	}
+	return nil
+}
+
+// compareNumbers returns -1, 0 or 1 if a is less than, equal to, or greater than b.
+// The boolean is false if the values are not numbers of the same kind.
+func compareNumbers(a, b reflect.Value) (int, bool) {
+	if a.Kind() != b.Kind() {
+		return 0, false
+	}
+	switch {
+	case a.CanInt():
+		return cmp.Compare(a.Int(), b.Int()), true
+	case a.CanUint():
+		return cmp.Compare(a.Uint(), b.Uint()), true
+	case a.CanFloat():
+		return cmp.Compare(a.Float(), b.Float()), true
+	default:
+		return 0, false
+	}
+}
+
+// appendOnlyMutationError handles slices and maps tagged with `nais:"appendonly"`.
+// Elements may be added and changed, but not removed.
+//
+// Slice elements are matched using their `nais:"key"` fields, or compared as a whole if no keys are defined.
+// If the tag has a value, e.g. `nais:"appendonly=cascadingDelete"`, it names a boolean field on the element
+// which explicitly allows removal of the element when set to true in the old object.
+func appendOnlyMutationError(new, old reflect.Value, path *field.Path, allowRemoval string) (allErrs field.ErrorList) {
+	removalAllowed := func(elem reflect.Value) bool {
+		if len(allowRemoval) == 0 || elem.Kind() != reflect.Struct {
+			return false
+		}
+		flag, ok := fieldByJSONName(elem, allowRemoval)
+		return ok && flag.Kind() == reflect.Bool && flag.Bool()
+	}
+
+	switch old.Kind() {
+	case reflect.Map:
+		for _, key := range sortedKeys(old) {
+			if new.Len() > 0 && new.MapIndex(key).IsValid() {
+				continue
+			}
+			elem, _ := indirect(old.MapIndex(key))
+			if !removalAllowed(elem) {
+				allErrs = append(allErrs, field.Forbidden(path.Key(key.String()), "entries cannot be removed from this field"))
+			}
+		}
+		return allErrs
+
+	case reflect.Slice, reflect.Array:
+		var keys []string
+		if elemType := indirectType(old.Type().Elem()); elemType.Kind() == reflect.Struct {
+			keys = planFor(elemType).keys
+		}
+
+	OUTER:
+		for i := 0; i < old.Len(); i++ {
+			oldVal, ok := indirect(old.Index(i))
+			if !ok {
+				continue
+			}
+			for j := 0; j < new.Len(); j++ {
+				newVal, ok := indirect(new.Index(j))
+				if ok && elementsMatch(newVal, oldVal, keys) {
+					continue OUTER
+				}
+			}
+			if !removalAllowed(oldVal) {
+				allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf("elements cannot be removed from this list; missing %s", describeElement(oldVal, keys))))
+			}
+		}
+		return allErrs
+
+	default:
+		return nil
+	}
+}
+
+// elementsMatch returns true if any of the key fields are equal, or if the elements are equal when no keys are given.
+func elementsMatch(a, b reflect.Value, keys []string) bool {
+	if len(keys) == 0 {
+		return !valuesDiffer(a, b)
+	}
+	for _, key := range keys {
+		if !valuesDiffer(a.FieldByName(key), b.FieldByName(key)) {
+			return true
+		}
 	}
-	return tags
+	return false
 }
 
-// jsonName returns the name of the field by default, override if `json:"name"` is set
-func jsonName(field reflect.StructField) string {
-	t := field.Tag.Get("json")
-	tag := field.Name
-	parts := strings.Split(t, ",")
-	if parts[0] != "" {
-		tag = parts[0]
+// describeElement formats the key fields of an element for use in error messages.
+func describeElement(elem reflect.Value, keys []string) string {
+	if len(keys) == 0 {
+		return fmt.Sprintf("%v", elem.Interface())
 	}
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		structField, _ := elem.Type().FieldByName(key)
+		parts = append(parts, fmt.Sprintf("%s=%v", jsonName(structField), elem.FieldByName(key).Interface()))
+	}
+	return strings.Join(parts, ",")
+}
 
-	return tag
+// isUnset returns true if the value is a nil pointer, an empty slice or map, or the zero value of its type.
+func isUnset(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
 }
 
 func valuesDiffer(new, old reflect.Value) bool {
 	return !reflect.DeepEqual(new.Interface(), old.Interface())
 }
 
-// sliceMutationError compares two slices, and returns errors if they differ
-//
-//	Does not support slices of pointers (pointers of any kind), potential future todo.
+// sliceMutationError compares two slices or arrays, and returns errors if they differ.
+// Elements may be structs or pointers to structs; nil elements are never compared.
 func sliceMutationError(new, old reflect.Value, path *field.Path) (allErrs field.ErrorList) {
 	if new.Len() == 0 || old.Len() == 0 {
 		// We don't want to compare unless both slices have elements within them
 		return allErrs
 	}
-	if new.Type().Elem().Kind() != reflect.Struct {
+	elemType := indirectType(new.Type().Elem())
+	if elemType.Kind() != reflect.Struct {
 		// Immutability tag can only be used on fields within a struct
 		return allErrs
 	}
 
-	keys := keysToCheck(new.Index(0).Type())
-	if len(keys) == 0 && doPanic {
-		panic("No `nais:\"key\"` defined for type on field" + path.String())
+	plan := planFor(elemType)
+	if !plan.compare {
+		return allErrs
 	}
+	// Elements cannot be matched without a `nais:"key"`; use Lint to catch this in tests
+	keys := plan.keys
 
 OUTER:
 	for i := 0; i < new.Len(); i++ {
 		// For each element in "new" slice
-		newVal := new.Index(i)
+		newVal, ok := indirect(new.Index(i))
+		if !ok {
+			continue
+		}
 
 		for j := 0; j < old.Len(); j++ {
 			// compared with each element in "old" slice
-			oldVal := old.Index(j)
+			oldVal, ok := indirect(old.Index(j))
+			if !ok {
+				continue
+			}
 			for _, key := range keys {
-				// Use the comparison keys found with keysToCheck to compare the elements from the two slices
+				// Use the `nais:"key"` fields of the element type to compare the elements from the two slices
 				if !valuesDiffer(newVal.FieldByName(key), oldVal.FieldByName(key)) {
 					errs := compareObjects(newVal, oldVal, path.Child(fmt.Sprint(i)))
 					if errs != nil {
@@ -143,13 +317,62 @@ OUTER:
 	return allErrs
 }
 
-func keysToCheck(typ reflect.Type) (ret []string) {
-	for field := range typ.Fields() {
-		properties := propertyMap(field)
-		if properties["key"] {
-			ret = append(ret, field.Name)
+// mapMutationError compares the entries of two maps keyed by string.
+// Entries present in both maps are compared recursively, using the map key as their identity.
+// Added and removed entries are not considered mutations.
+func mapMutationError(new, old reflect.Value, path *field.Path) (allErrs field.ErrorList) {
+	if new.Len() == 0 || old.Len() == 0 {
+		return allErrs
+	}
+	if new.Type().Key().Kind() != reflect.String {
+		return allErrs
+	}
+	elemType := indirectType(new.Type().Elem())
+	if elemType.Kind() != reflect.Struct || !planFor(elemType).compare {
+		// Immutability tag can only be used on fields within a struct
+		return allErrs
+	}
+
+	for _, key := range sortedKeys(new) {
+		oldVal := old.MapIndex(key)
+		if !oldVal.IsValid() {
+			continue
 		}
+		newVal, newOk := indirect(new.MapIndex(key))
+		oldVal, oldOk := indirect(oldVal)
+		if !newOk || !oldOk {
+			continue
+		}
+		allErrs = append(allErrs, compareObjects(newVal, oldVal, path.Key(key.String()))...)
 	}
 
-	return ret
+	return allErrs
+}
+
+// sortedKeys returns the keys of a map keyed by string in sorted order.
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+	return keys
+}
+
+// indirect dereferences a pointer value. The boolean is false if the pointer is nil.
+func indirect(v reflect.Value) (reflect.Value, bool) {
+	if v.Kind() != reflect.Pointer {
+		return v, true
+	}
+	if v.IsNil() {
+		return v, false
+	}
+	return v.Elem(), true
+}
+
+// indirectType returns the element type of a pointer type, or the type itself.
+func indirectType(typ reflect.Type) reflect.Type {
+	if typ.Kind() == reflect.Pointer {
+		return typ.Elem()
+	}
+	return typ
 }
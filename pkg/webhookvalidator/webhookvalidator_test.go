@@ -212,6 +212,209 @@ func TestDeepComparison(t *testing.T) {
 				},
 			},
 		},
+		"Slice of struct pointers with immutable fields fail if not equal on key": {
+			New: collectionStruct{
+				SlicePtr: []*SmallStruct{
+					nil,
+					{A: 2, C: 5},
+				},
+			},
+			Old: collectionStruct{
+				SlicePtr: []*SmallStruct{
+					{A: 2, C: 8},
+				},
+			},
+			TestErrors: []string{
+				"test.SlicePtr.1.C",
+			},
+		},
+		"Slice of struct pointers with immutable fields pass if equal on key": {
+			New: collectionStruct{
+				SlicePtr: []*SmallStruct{
+					{A: 2, B: 1, C: 5},
+				},
+			},
+			Old: collectionStruct{
+				SlicePtr: []*SmallStruct{
+					{A: 2, B: 2, C: 5},
+					nil,
+				},
+			},
+		},
+		"Array of struct with immutable fields fail if not equal on key": {
+			New: collectionStruct{
+				Array: [2]SmallStruct{{A: 1, C: 1}, {A: 2, C: 2}},
+			},
+			Old: collectionStruct{
+				Array: [2]SmallStruct{{A: 2, C: 3}, {A: 1, C: 1}},
+			},
+			TestErrors: []string{
+				"test.Array.1.C",
+			},
+		},
+		"Map of struct with immutable fields fail if not equal on key": {
+			New: collectionStruct{
+				MapStruct: map[string]SmallStruct{
+					"foo": {A: 1, C: 1},
+					"bar": {A: 1, C: 2},
+				},
+			},
+			Old: collectionStruct{
+				MapStruct: map[string]SmallStruct{
+					"foo": {A: 1, C: 1},
+					"bar": {A: 1, C: 3},
+				},
+			},
+			TestErrors: []string{
+				"test.MapStruct[bar].C",
+			},
+		},
+		"Map of struct pointers with immutable fields fail if not equal on key": {
+			New: collectionStruct{
+				MapPtr: map[string]*SmallStruct{
+					"foo": {A: 1},
+					"bar": {A: 2},
+				},
+			},
+			Old: collectionStruct{
+				MapPtr: map[string]*SmallStruct{
+					"foo": {A: 3},
+					"bar": {A: 2},
+				},
+			},
+			TestErrors: []string{
+				"test.MapPtr[foo].A",
+			},
+		},
+		"Map of struct pass when entries are added or removed": {
+			New: collectionStruct{
+				MapStruct: map[string]SmallStruct{
+					"foo": {A: 1},
+					"baz": {A: 5},
+				},
+				MapPtr: map[string]*SmallStruct{
+					"foo": nil,
+				},
+			},
+			Old: collectionStruct{
+				MapStruct: map[string]SmallStruct{
+					"foo": {A: 1},
+					"bar": {A: 3},
+				},
+				MapPtr: map[string]*SmallStruct{
+					"foo": {A: 3},
+				},
+			},
+		},
+		"Once-set fields pass when set for the first time": {
+			New: onceSetStruct{Str: "a", Ptr: new(1)},
+			Old: onceSetStruct{},
+		},
+		"Once-set fields pass if equal": {
+			New: onceSetStruct{Str: "a", Ptr: new(1), Strict: new("b")},
+			Old: onceSetStruct{Str: "a", Ptr: new(1), Strict: new("b")},
+		},
+		"Once-set fields fail if changed": {
+			New: onceSetStruct{Str: "a", Ptr: new(1)},
+			Old: onceSetStruct{Str: "b", Ptr: new(2)},
+			TestErrors: []string{
+				"test.Str",
+				"test.Ptr",
+			},
+		},
+		"Once-set fields fail if removed": {
+			New: onceSetStruct{},
+			Old: onceSetStruct{Str: "a", Ptr: new(1)},
+			TestErrors: []string{
+				"test.Str",
+				"test.Ptr",
+			},
+		},
+		"Strict field fail if set after creation": {
+			New: onceSetStruct{Strict: new("b")},
+			Old: onceSetStruct{},
+			TestErrors: []string{
+				"test.Strict",
+			},
+		},
+		"Strict field fail if removed": {
+			New: onceSetStruct{},
+			Old: onceSetStruct{Strict: new("b")},
+			TestErrors: []string{
+				"test.Strict",
+			},
+		},
+		"Append-only list pass when elements are added and changed": {
+			New: appendOnlyStruct{
+				Keyed:  []SmallStruct{{A: 1, B: 2}, {A: 2}},
+				Plain:  []string{"a", "b", "c"},
+				Mapped: map[string]int{"a": 2, "b": 1},
+			},
+			Old: appendOnlyStruct{
+				Keyed:  []SmallStruct{{A: 1, B: 1}},
+				Plain:  []string{"a", "b"},
+				Mapped: map[string]int{"a": 1},
+			},
+		},
+		"Append-only list fail when elements are removed": {
+			New: appendOnlyStruct{
+				Keyed:  []SmallStruct{{A: 2}},
+				Plain:  []string{"b"},
+				Mapped: map[string]int{"b": 1},
+			},
+			Old: appendOnlyStruct{
+				Keyed:  []SmallStruct{{A: 1}, {A: 2}},
+				Plain:  []string{"a", "b"},
+				Mapped: map[string]int{"a": 1, "b": 1},
+			},
+			TestErrors: []string{
+				"test.keyed",
+				"test.plain",
+				"test.mapped[a]",
+			},
+		},
+		"Append-only list pass when removed element allows removal": {
+			New: appendOnlyStruct{
+				Deletable: []deletable{{Name: "b"}},
+			},
+			Old: appendOnlyStruct{
+				Deletable: []deletable{{Name: "a", CascadingDelete: true}, {Name: "b"}},
+			},
+		},
+		"Append-only list fail when removed element does not allow removal": {
+			New: appendOnlyStruct{
+				Deletable: []deletable{{Name: "a", CascadingDelete: true}},
+			},
+			Old: appendOnlyStruct{
+				Deletable: []deletable{{Name: "a", CascadingDelete: true}, {Name: "b"}},
+			},
+			TestErrors: []string{
+				"test.deletable",
+			},
+		},
+		"Monotonic fields pass when changed in the right direction": {
+			New: appendOnlyStruct{Increasing: 20, Decreasing: new(1.5)},
+			Old: appendOnlyStruct{Increasing: 10, Decreasing: new(2.5)},
+		},
+		"Monotonic fields pass when set for the first time": {
+			New: appendOnlyStruct{Increasing: 20, Decreasing: new(1.5)},
+			Old: appendOnlyStruct{},
+		},
+		"Monotonic fields fail when changed in the wrong direction": {
+			New: appendOnlyStruct{Increasing: 10, Decreasing: new(2.5)},
+			Old: appendOnlyStruct{Increasing: 20, Decreasing: new(1.5)},
+			TestErrors: []string{
+				"test.increasing",
+				"test.decreasing",
+			},
+		},
+		"Monotonic pointer field fail when removed": {
+			New: appendOnlyStruct{},
+			Old: appendOnlyStruct{Decreasing: new(1.5)},
+			TestErrors: []string{
+				"test.decreasing",
+			},
+		},
 	}
 
 	for name, tt := range tests {
@@ -272,3 +475,30 @@ type ptrStructImmutable struct {
 	Pint    *int         `nais:"immutable"`
 	PStruct *SmallStruct `nais:"immutable"`
 }
+
+type collectionStruct struct {
+	SlicePtr  []*SmallStruct
+	Array     [2]SmallStruct
+	MapStruct map[string]SmallStruct
+	MapPtr    map[string]*SmallStruct
+}
+
+type onceSetStruct struct {
+	Str    string  `nais:"immutable,onceset"`
+	Ptr    *int    `nais:"immutable,onceset"`
+	Strict *string `nais:"immutable,strict"`
+}
+
+type deletable struct {
+	Name            string `json:"name" nais:"key"`
+	CascadingDelete bool   `json:"cascadingDelete"`
+}
+
+type appendOnlyStruct struct {
+	Keyed      []SmallStruct  `json:"keyed" nais:"appendonly"`
+	Plain      []string       `json:"plain" nais:"appendonly"`
+	Mapped     map[string]int `json:"mapped" nais:"appendonly"`
+	Deletable  []deletable    `json:"deletable" nais:"appendonly=cascadingDelete"`
+	Increasing int            `json:"increasing" nais:"monotonic=increase"`
+	Decreasing *float64       `json:"decreasing" nais:"monotonic=decrease"`
+}